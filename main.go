@@ -1,21 +1,21 @@
 package main
 
 import (
-	"crypto/md5"
-	"encoding/hex"
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"os/user"
-	"path"
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
 	"strings"
 
 	"github.com/incu6us/goimports-reviser/v3/helper"
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+	"github.com/incu6us/goimports-reviser/v3/pkg/cache"
+	"github.com/incu6us/goimports-reviser/v3/pkg/git"
 	"github.com/incu6us/goimports-reviser/v3/reviser"
 )
 
@@ -36,8 +36,39 @@ const (
 	applyToGeneratedFiles  = "apply-to-generated-files"
 	excludesArg            = "excludes"
 	// using a regex here so that this will work with forked repos (at least on github.com)
-	modulePathRegex  = `^github.com/[\w-]+/goimports-reviser(/v\d+)?@?`
-	separateNamedArg = "separate-named"
+	modulePathRegex        = `^github.com/[\w-]+/goimports-reviser(/v\d+)?@?`
+	separateNamedArg       = "separate-named"
+	canonicalAliasesArg    = "canonical-aliases"
+	setAliasTemplateArg    = "set-alias-template"
+	rmRedundantAliasArg    = "rm-redundant-alias"
+	resolveCollisionsArg   = "resolve-collisions"
+	collisionsPriorityArg  = "collisions-priority"
+	rmDotImportsArg        = "rm-dot-imports"
+	dotImportsAllowlistArg = "dot-imports-allowlist"
+	importHygieneArg       = "import-hygiene"
+	keepGroupHeadersArg    = "keep-group-headers"
+	groupHeadersArg        = "group-headers"
+	keepBlankOrderArg      = "keep-blank-order"
+	keepBlankOrderPathsArg = "keep-blank-order-paths"
+	preserveGroupsArg      = "preserve-groups"
+	stdFirstArg            = "std-first"
+	tagsArg                = "tags"
+	goosArg                = "goos"
+	goarchArg              = "goarch"
+	packageResolverArg     = "package-resolver"
+	addMissingArg          = "add-missing"
+	modernizeArg           = "modernize"
+	deprecatedImportsArg   = "deprecated-imports"
+	majorVersionsArg       = "major-versions"
+	fixMajorVersionsArg    = "fix-major-versions"
+	workersArg             = "j"
+	cacheDirArg            = "cache-dir"
+	cacheMaxSizeArg        = "cache-max-size"
+	changedSinceArg        = "changed-since"
+	stagedArg              = "staged"
+
+	cacheCommand = "cache"
+	cleanCommand = "clean"
 
 	// Deprecated options
 	localArg    = "local"
@@ -54,7 +85,20 @@ var (
 	shouldShowVersion           *bool
 	shouldShowVersionOnly       *bool
 	shouldRemoveUnusedImports   *bool
+	shouldAddMissingImports     *bool
+	shouldModernize             *bool
 	shouldSetAlias              *bool
+	shouldRemoveRedundantAlias  *bool
+	shouldResolveCollisions     *bool
+	shouldRemoveDotImports      *bool
+	shouldCheckImportHygiene    *bool
+	shouldCheckDeprecated       *bool
+	shouldCheckMajorVersions    *bool
+	shouldFixMajorVersions      *bool
+	shouldKeepGroupHeaders      *bool
+	shouldKeepBlankOrder        *bool
+	shouldPreserveGroups        *bool
+	shouldMoveStdFirst          *bool
 	shouldFormat                *bool
 	shouldApplyToGeneratedFiles *bool
 	shouldSeparateNamedImports  *bool
@@ -62,11 +106,14 @@ var (
 	setExitStatus               *bool
 	isRecursive                 *bool
 	isUseCache                  *bool
+	workers                     *int
+	cacheMaxSize                *int
+	isStaged                    *bool
 	modulePathMatcher           = regexp.MustCompile(modulePathRegex)
 )
 
 var (
-	projectName, companyPkgPrefixes, output, importsOrder, excludes string
+	projectName, companyPkgPrefixes, output, importsOrder, excludes, canonicalAliases, setAliasTemplate, collisionsPriority, dotImportsAllowlist, groupHeaders, keepBlankOrderPaths, buildTags, goos, goarch, packageResolver, cacheDir, changedSince string
 
 	// Deprecated
 	localPkgPrefixes, filePath string
@@ -129,6 +176,14 @@ dotted - imports with "." alias.
 Optional parameter.`,
 	)
 
+	flag.StringVar(
+		&canonicalAliases,
+		canonicalAliasesArg,
+		"",
+		"Required aliases for import paths which will be applied to every import and all its uses in the file. "+
+			"Values should be comma-separated, example: 'k8s.io/api/core/v1=corev1,k8s.io/api/apps/v1=appsv1'. Optional parameter.",
+	)
+
 	listFileName = flag.Bool(
 		listDiffFileNameArg,
 		false,
@@ -138,7 +193,7 @@ Optional parameter.`,
 	setExitStatus = flag.Bool(
 		setExitStatusArg,
 		false,
-		"set the exit status to 1 if a change is needed/made. Optional parameter.",
+		"set the exit status to 1 if a change is needed/made or a problem is reported. Optional parameter.",
 	)
 
 	shouldRemoveUnusedImports = flag.Bool(
@@ -147,6 +202,20 @@ Optional parameter.`,
 		"Remove unused imports. Optional parameter.",
 	)
 
+	shouldAddMissingImports = flag.Bool(
+		addMissingArg,
+		false,
+		"Add imports which are required by compiler directives: '_ \"embed\"' for '//go:embed' and '_ \"unsafe\"' for '//go:linkname'. Optional parameter.",
+	)
+
+	shouldModernize = flag.Bool(
+		modernizeArg,
+		false,
+		"Migrate imports to std packages which are available for the Go version of go.mod: 'golang.org/x/exp/slices' and "+
+			"'golang.org/x/exp/maps' to 'slices' and 'maps'(go 1.21), 'io/ioutil' to 'io' and 'os'(go 1.16). "+
+			"Uses with compatible signatures are rewritten. Optional parameter.",
+	)
+
 	shouldSetAlias = flag.Bool(
 		setAliasArg,
 		false,
@@ -154,6 +223,160 @@ Optional parameter.`,
 			"In this case import will be set as 'pg \"github.com/go-pg/pg/v9\"'. Optional parameter.",
 	)
 
+	shouldRemoveRedundantAlias = flag.Bool(
+		rmRedundantAliasArg,
+		false,
+		"Remove aliases which are equal to the real package name, like 'errors \"errors\"'. "+
+			"Aliases which resolve a collision with another import are kept. Optional parameter.",
+	)
+
+	shouldResolveCollisions = flag.Bool(
+		resolveCollisionsArg,
+		false,
+		"Set aliases for imports with colliding package names(ex.: 'log' and 'github.com/sirupsen/log') "+
+			"and rewrite their uses in the file. Uses which are exported by several colliding packages are kept for the "+
+			"package with the highest priority and reported. Optional parameter.",
+	)
+
+	flag.StringVar(
+		&collisionsPriority,
+		collisionsPriorityArg,
+		"std,project,company,general",
+		"Groups which keep their package names on collision, in order of priority. Used with '-resolve-collisions'. Optional parameter.",
+	)
+
+	shouldRemoveDotImports = flag.Bool(
+		rmDotImportsArg,
+		false,
+		"Convert dot imports into regular imports and qualify identifiers which were resolved through them. Optional parameter.",
+	)
+
+	flag.StringVar(
+		&dotImportsAllowlist,
+		dotImportsAllowlistArg,
+		"github.com/onsi/ginkgo,github.com/onsi/ginkgo/v2,github.com/onsi/gomega",
+		"Packages(and their subpackages) which are allowed to be dot-imported with '-rm-dot-imports'. Values should be comma-separated. Optional parameter.",
+	)
+
+	shouldCheckImportHygiene = flag.Bool(
+		importHygieneArg,
+		false,
+		"Report blank imports without a trailing comment justifying them outside of main and test packages, "+
+			"and testing packages(testing, testify, gomock) imported from non-test files. "+
+			"Together with '-set-exit-status' the exit status will be 1 if any violation is found. Optional parameter.",
+	)
+
+	shouldCheckDeprecated = flag.Bool(
+		deprecatedImportsArg,
+		false,
+		"Report imports of packages which documentation has a paragraph starting with 'Deprecated:'. "+
+			"Documentation of std packages and module dependencies is read offline. "+
+			"Together with '-set-exit-status' the exit status will be 1 if any deprecated import is found. Optional parameter.",
+	)
+
+	shouldCheckMajorVersions = flag.Bool(
+		majorVersionsArg,
+		false,
+		"Report imports which major version doesn't match the module required by go.mod(ex.: 'github.com/foo/bar' while "+
+			"go.mod requires 'github.com/foo/bar/v3') and files which import different major versions of the same module. "+
+			"Together with '-set-exit-status' the exit status will be 1 if any mismatch is found. Optional parameter.",
+	)
+
+	shouldFixMajorVersions = flag.Bool(
+		fixMajorVersionsArg,
+		false,
+		"Rewrite imports to the major version of the module required by go.mod. "+
+			"Together with '-set-alias' the rewritten imports get the alias. Optional parameter.",
+	)
+
+	workers = flag.Int(
+		workersArg,
+		0,
+		"Number of files which are processed in parallel in directory mode. GOMAXPROCS is used by default. Optional parameter.",
+	)
+
+	shouldKeepGroupHeaders = flag.Bool(
+		keepGroupHeadersArg,
+		false,
+		"Keep comments which open import groups(ex.: '// internal') above the group where their imports are placed after sorting. Optional parameter.",
+	)
+
+	flag.StringVar(
+		&groupHeaders,
+		groupHeadersArg,
+		"",
+		"Header comments which will be put above import groups. Existing headers are replaced. Values should be comma-separated, "+
+			"example: 'std=Standard library,general=Third party,company=Acme'. Optional parameter.",
+	)
+
+	shouldKeepBlankOrder = flag.Bool(
+		keepBlankOrderArg,
+		false,
+		"Keep the original relative order of blank imports inside of their groups(ex.: for init order sensitive drivers). Optional parameter.",
+	)
+
+	flag.StringVar(
+		&keepBlankOrderPaths,
+		keepBlankOrderPathsArg,
+		"",
+		"Packages(and their subpackages) whose blank imports keep the original order with '-keep-blank-order'. All blank imports if it's empty. Values should be comma-separated. Optional parameter.",
+	)
+
+	shouldPreserveGroups = flag.Bool(
+		preserveGroupsArg,
+		false,
+		"Keep existing groups of imports(separated with an empty line) and sort imports inside of each group only. "+
+			"'-imports-order' is not used in this mode. Optional parameter.",
+	)
+
+	shouldMoveStdFirst = flag.Bool(
+		stdFirstArg,
+		false,
+		"Move std imports into the first group. Used with '-preserve-groups'. Optional parameter.",
+	)
+
+	flag.StringVar(
+		&buildTags,
+		tagsArg,
+		"",
+		"Build tags which are used to load packages. Tags from the build constraint of the file are added automatically. "+
+			"Values should be comma-separated. Optional parameter.",
+	)
+
+	flag.StringVar(
+		&goos,
+		goosArg,
+		"",
+		"Target operating systems which are used to load packages(current $GOOS by default). Unused imports are removed only if they are "+
+			"unused for every target where the file is built. Values should be comma-separated, example: 'linux,darwin,windows'. Optional parameter.",
+	)
+
+	flag.StringVar(
+		&goarch,
+		goarchArg,
+		"",
+		"Target architectures which are used to load packages(current $GOARCH by default). Values should be comma-separated, "+
+			"example: 'amd64,arm64'. Optional parameter.",
+	)
+
+	flag.StringVar(
+		&packageResolver,
+		packageResolverArg,
+		"",
+		"Resolvers of the imported package names which are tried in order: golist, vendor, modcache(reads sources from the module cache), "+
+			"heuristic(assumes the name from the import path). Values should be comma-separated, example: 'vendor,modcache,heuristic'. "+
+			"'golist' is used if it's empty. Optional parameter.",
+	)
+
+	flag.StringVar(
+		&setAliasTemplate,
+		setAliasTemplateArg,
+		"",
+		"Template of the alias which will be set together with '-set-alias' for packages with version-like names(ex.: 'k8s.io/api/apps/v1') "+
+			"or names which collide with another import. Placeholders: {{name}}, {{parent}}, {{version}}. "+
+			"Example: '{{parent}}{{version}}' will set alias 'appsv1'. Optional parameter.",
+	)
+
 	shouldFormat = flag.Bool(
 		formatArg,
 		false,
@@ -175,7 +398,39 @@ Optional parameter.`,
 	isUseCache = flag.Bool(
 		useCacheArg,
 		false,
-		"Use cache to improve performance. Optional parameter.",
+		"Use cache of results to improve performance. A result is reused while the file content, options, go.mod, "+
+			"imported local packages and the tool version are the same. Works for files and directories. Optional parameter.",
+	)
+
+	flag.StringVar(
+		&cacheDir,
+		cacheDirArg,
+		"",
+		"Directory of the cache which is used with '-use-cache'. '$XDG_CACHE_HOME/goimports-reviser' or "+
+			"'~/.cache/goimports-reviser' is used by default. Run 'goimports-reviser cache clean' to remove cached results. "+
+			"Optional parameter.",
+	)
+
+	cacheMaxSize = flag.Int(
+		cacheMaxSizeArg,
+		int(cache.DefaultMaxSize>>20),
+		"Max size of the cache in megabytes. Least recently used results are removed after the run. Optional parameter.",
+	)
+
+	flag.StringVar(
+		&changedSince,
+		changedSinceArg,
+		"",
+		"Process only Go files which were changed since the git ref(committed, staged, unstaged and untracked files). "+
+			"Paths, if set, limit the files. Optional parameter.",
+	)
+
+	isStaged = flag.Bool(
+		stagedArg,
+		false,
+		"Process only Go files which have staged changes in git. The content is read from the index, the fixed content is "+
+			"written to the index and to the work tree(if the file has no unstaged changes). Paths, if set, limit the files. "+
+			"Optional parameter.",
 	)
 
 	shouldApplyToGeneratedFiles = flag.Bool(
@@ -297,11 +552,29 @@ func main() {
 
 	originPaths := flag.Args()
 
+	if len(originPaths) == 2 && originPaths[0] == cacheCommand && originPaths[1] == cleanCommand {
+		cleanCache()
+		return
+	}
+
 	if filePath != "" {
 		deprecatedMessagesCh <- fmt.Sprintf("-%s is deprecated. Put file name(s) as last argument to the command(Example: goimports-reviser -rm-unused -set-alias -format goimports-reviser/main.go)", filePathArg)
 		originPaths = append(originPaths, filePath)
 	}
 
+	var repo *git.Repository
+	if changedSince != "" || *isStaged {
+		var err error
+		repo, originPaths, err = gitFiles(originPaths)
+		if err != nil {
+			printUsageAndExit(err)
+		}
+		if len(originPaths) == 0 {
+			log.Println("No changed Go files")
+			return
+		}
+	}
+
 	if len(originPaths) == 0 {
 		printUsageAndExit(errors.New("no file(s) or directory(ies) specified on input"))
 	}
@@ -322,6 +595,68 @@ func main() {
 		options = append(options, reviser.WithUsingAliasForVersionSuffix)
 	}
 
+	if shouldRemoveRedundantAlias != nil && *shouldRemoveRedundantAlias {
+		options = append(options, reviser.WithRemovingRedundantAliases)
+	}
+
+	if shouldResolveCollisions != nil && *shouldResolveCollisions {
+		priority, err := reviser.StringToCollisionPriority(collisionsPriority)
+		if err != nil {
+			printUsageAndExit(err)
+		}
+		options = append(options, reviser.WithResolvingNameCollisions(priority))
+	}
+
+	if shouldModernize != nil && *shouldModernize {
+		options = append(options, reviser.WithModernization)
+	}
+
+	if shouldAddMissingImports != nil && *shouldAddMissingImports {
+		options = append(options, reviser.WithAddingMissingImports)
+	}
+
+	if shouldRemoveDotImports != nil && *shouldRemoveDotImports {
+		options = append(options, reviser.WithDotImportsElimination(splitCommaSeparated(dotImportsAllowlist)))
+	}
+
+	if shouldCheckImportHygiene != nil && *shouldCheckImportHygiene {
+		options = append(options, reviser.WithImportHygieneRules(nil))
+	}
+
+	if shouldCheckDeprecated != nil && *shouldCheckDeprecated {
+		options = append(options, reviser.WithDeprecatedImportsCheck)
+	}
+
+	if shouldCheckMajorVersions != nil && *shouldCheckMajorVersions {
+		options = append(options, reviser.WithMajorVersionCheck)
+	}
+
+	if shouldFixMajorVersions != nil && *shouldFixMajorVersions {
+		options = append(options, reviser.WithMajorVersionFix)
+	}
+
+	if shouldKeepGroupHeaders != nil && *shouldKeepGroupHeaders {
+		options = append(options, reviser.WithKeepingGroupHeaders)
+	}
+
+	if shouldKeepBlankOrder != nil && *shouldKeepBlankOrder {
+		options = append(options, reviser.WithPreservedBlankImportsOrder(splitCommaSeparated(keepBlankOrderPaths)))
+	}
+
+	if shouldPreserveGroups != nil && *shouldPreserveGroups {
+		options = append(options, reviser.WithPreservedImportGroups(shouldMoveStdFirst != nil && *shouldMoveStdFirst))
+	}
+
+	if setAliasTemplate != "" {
+		options = append(options, reviser.WithAliasTemplate(setAliasTemplate))
+	}
+
+	if buildTags != "" || goos != "" || goarch != "" {
+		options = append(options, reviser.WithBuildContexts(astutil.BuildContexts(
+			splitCommaSeparated(goos), splitCommaSeparated(goarch), splitCommaSeparated(buildTags),
+		)))
+	}
+
 	if shouldFormat != nil && *shouldFormat {
 		options = append(options, reviser.WithCodeFormatting)
 	}
@@ -353,8 +688,42 @@ func main() {
 		options = append(options, reviser.WithImportsOrder(order))
 	}
 
+	if canonicalAliases != "" {
+		aliases, err := reviser.StringToCanonicalAliases(canonicalAliases)
+		if err != nil {
+			printUsageAndExit(err)
+		}
+		options = append(options, reviser.WithCanonicalAliases(aliases))
+	}
+
+	if groupHeaders != "" {
+		headers, err := reviser.StringToGroupHeaders(groupHeaders)
+		if err != nil {
+			printUsageAndExit(err)
+		}
+		options = append(options, reviser.WithGroupHeaders(headers))
+	}
+
+	if packageResolver != "" {
+		resolver, err := reviser.StringToPackageNameResolver(packageResolver)
+		if err != nil {
+			printUsageAndExit(err)
+		}
+		options = append(options, reviser.WithPackageNameResolver(resolver))
+	}
+
+	var resultCache *cache.Cache
+	if *isUseCache {
+		var err error
+		resultCache, err = cache.New(cacheDir, int64(*cacheMaxSize)<<20)
+		if err != nil {
+			log.Fatalf("Failed to create cache directory: %+v\n", err)
+		}
+		options = append(options, reviser.WithResultCache(resultCache, toolVersion()))
+	}
+
 	close(deprecatedMessagesCh)
-	var hasChange bool
+	var hasChange, hasDiagnostics bool
 	log.Printf("Paths: %v\n", originPaths)
 	for _, originPath := range originPaths {
 		log.Printf("Processing %s\n", originPath)
@@ -363,26 +732,32 @@ func main() {
 			printUsageAndExit(fmt.Errorf("Could not determine project name for path %s: %s", originPath, err))
 		}
 		if _, ok := reviser.IsDir(originPath); ok {
+			sourceDir := reviser.NewSourceDir(
+				originProjectName, originPath, *isRecursive, excludes, reviser.WithWorkers(*workers),
+			)
 			if *listFileName {
-				unformattedFiles, err := reviser.NewSourceDir(originProjectName, originPath, *isRecursive, excludes).Find(options...)
+				unformattedFiles, err := sourceDir.Find(options...)
 				if err != nil {
 					log.Fatalf("Failed to find unformatted files %s: %+v\n", originPath, err)
 				}
 
+				hasDiagnostics = printDiagnostics(sourceDir.Diagnostics()) || hasDiagnostics
 				if unformattedFiles != nil {
 					fmt.Printf("%s\n", unformattedFiles.String())
-					if *setExitStatus {
-						os.Exit(1)
-					}
+				}
+				pruneCache(resultCache)
+				if (unformattedFiles != nil || hasDiagnostics) && *setExitStatus {
+					os.Exit(1)
 				}
 
 				return
 			}
 
-			err := reviser.NewSourceDir(originProjectName, originPath, *isRecursive, excludes).Fix(options...)
+			err := sourceDir.Fix(options...)
 			if err != nil {
 				log.Fatalf("Failed to fix directory %s: %+v\n", originPath, err)
 			}
+			hasDiagnostics = printDiagnostics(sourceDir.Diagnostics()) || hasDiagnostics
 
 			continue
 		}
@@ -394,76 +769,173 @@ func main() {
 			}
 		}
 
-		var formattedOutput []byte
-		var pathHasChange bool
-		if *isUseCache {
-			hash := md5.Sum([]byte(originPath))
-
-			u, err := user.Current()
-			if err != nil {
-				log.Fatalf("Failed to get current user: %+v\n", err)
-			}
-			cacheDir := path.Join(u.HomeDir, ".cache", "goimports-reviser")
-			if err = os.MkdirAll(cacheDir, os.ModePerm); err != nil {
-				log.Fatalf("Failed to create cache directory: %+v\n", err)
-			}
-			cacheFile := path.Join(cacheDir, hex.EncodeToString(hash[:]))
-
-			var cacheContent, fileContent []byte
-			if cacheContent, err = os.ReadFile(cacheFile); err == nil {
-				// compare file content hash
-				var fileHashHex string
-				if fileContent, err = os.ReadFile(originPath); err == nil {
-					fileHash := md5.Sum(fileContent)
-					fileHashHex = hex.EncodeToString(fileHash[:])
-				}
-				if string(cacheContent) == fileHashHex {
-					// point to cache
-					continue
-				}
-			}
-			formattedOutput, _, pathHasChange, err = reviser.NewSourceFile(originProjectName, originPath).Fix(options...)
-			if err != nil {
-				log.Fatalf("Failed to fix file: %+v\n", err)
-			}
-			fileHash := md5.Sum(formattedOutput)
-			fileHashHex := hex.EncodeToString(fileHash[:])
-			if fileInfo, err := os.Stat(cacheFile); err != nil || fileInfo.IsDir() {
-				if _, err = os.Create(cacheFile); err != nil {
-					log.Fatalf("Failed to create cache file: %+v\n", err)
-				}
-			}
-			file, _ := os.OpenFile(cacheFile, os.O_RDWR, os.ModePerm)
-			defer func() {
-				_ = file.Close()
-			}()
-			if err = file.Truncate(0); err != nil {
-				log.Fatalf("Failed file truncate: %+v\n", err)
-			}
-			if _, err = file.Seek(0, 0); err != nil {
-				log.Fatalf("Failed file seek: %+v\n", err)
-			}
-			if _, err = file.WriteString(fileHashHex); err != nil {
-				log.Fatalf("Failed to write file hash: %+v\n", err)
+		fileOptions := options
+		var stagedContent []byte
+		if *isStaged {
+			if stagedContent, err = repo.ReadStaged(originPath); err != nil {
+				log.Fatalf("Failed to read staged file: %+v\n", err)
 			}
-		} else {
-			formattedOutput, _, pathHasChange, err = reviser.NewSourceFile(originProjectName, originPath).Fix(options...)
-			if err != nil {
-				log.Fatalf("Failed to fix file: %+v\n", err)
+			if stagedContent == nil {
+				stagedContent = []byte{}
 			}
+			fileOptions = append(fileOptions[:len(fileOptions):len(fileOptions)], reviser.WithSourceContent(stagedContent))
+		}
+
+		var formattedOutput []byte
+		var pathHasChange bool
+		sourceFile := reviser.NewSourceFile(originProjectName, originPath)
+		formattedOutput, _, pathHasChange, err = sourceFile.Fix(fileOptions...)
+		if err != nil {
+			log.Fatalf("Failed to fix file: %+v\n", err)
 		}
 		if !hasChange && pathHasChange {
 			hasChange = pathHasChange
 		}
+		hasDiagnostics = printDiagnostics(sourceFile.Diagnostics()) || hasDiagnostics
+
+		if *isStaged {
+			stagedPostProcess(repo, pathHasChange, originPath, formattedOutput, stagedContent)
+			continue
+		}
 
 		resultPostProcess(hasChange, originPath, formattedOutput)
 	}
 	printDeprecations(deprecatedMessagesCh)
-	if hasChange && *setExitStatus {
+	pruneCache(resultCache)
+	if (hasChange || hasDiagnostics) && *setExitStatus {
 		os.Exit(1)
 	}
 }
 
+// pruneCache removes least recently used results if the cache is bigger than its max size
+func pruneCache(resultCache *cache.Cache) {
+	if resultCache == nil {
+		return
+	}
+
+	if err := resultCache.Prune(); err != nil {
+		log.Printf("Failed to prune cache: %+v\n", err)
+	}
+}
+
+// cleanCache removes all results of the cache in '-cache-dir'
+func cleanCache() {
+	resultCache, err := cache.New(cacheDir, int64(*cacheMaxSize)<<20)
+	if err != nil {
+		log.Fatalf("Failed to open cache directory: %+v\n", err)
+	}
+
+	if err := resultCache.Clean(); err != nil {
+		log.Fatalf("Failed to clean cache: %+v\n", err)
+	}
+	fmt.Printf("Cache %s is cleaned\n", resultCache.Dir())
+}
+
+// toolVersion returns the version of the binary, so cached results of another version are not reused
+func toolVersion() string {
+	if Tag != "" {
+		return Tag + " " + Commit
+	}
+
+	bi := getBuildInfo()
+	if bi == nil {
+		return ""
+	}
+
+	version := bi.Main.Version
+	var hasRevision bool
+	for _, setting := range bi.Settings {
+		if strings.HasPrefix(setting.Key, "vcs.") {
+			version += " " + setting.Key + "=" + setting.Value
+			hasRevision = hasRevision || setting.Key == "vcs.revision"
+		}
+	}
+
+	// development builds without VCS info are distinguished by the binary itself
+	if !hasRevision {
+		if executable, err := os.Executable(); err == nil {
+			if fi, err := os.Stat(executable); err == nil {
+				version += fmt.Sprintf(" %s %d %d", executable, fi.Size(), fi.ModTime().UnixNano())
+			}
+		}
+	}
+
+	return version
+}
+
+// gitFiles returns the repository of the current directory and Go files which were changed since '-changed-since' ref
+// or staged, limited to paths. Files which are excluded with '-excludes' are skipped.
+func gitFiles(paths []string) (*git.Repository, []string, error) {
+	if changedSince != "" && *isStaged {
+		return nil, nil, fmt.Errorf("-%s and -%s can't be used together", changedSinceArg, stagedArg)
+	}
+
+	pathspecs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		pathspecs = append(pathspecs, strings.TrimSuffix(path, "..."))
+	}
+
+	repo, err := git.Open(".")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files []string
+	if *isStaged {
+		files, err = repo.StagedFiles(pathspecs...)
+	} else {
+		files, err = repo.ChangedFiles(changedSince, pathspecs...)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	excludesDir := reviser.NewSourceDir(projectName, ".", true, excludes)
+	goFiles := make([]string, 0, len(files))
+	for _, file := range files {
+		if filepath.Ext(file) == ".go" && !excludesDir.IsExcluded(file) {
+			goFiles = append(goFiles, file)
+		}
+	}
+
+	return repo, goFiles, nil
+}
+
+// stagedPostProcess outputs the result of the file which was read from git index. The fixed content is written to the
+// index, and to the work tree only if it has no unstaged changes of the file, so they are not lost.
+func stagedPostProcess(repo *git.Repository, hasChange bool, originFilePath string, formattedOutput, stagedContent []byte) {
+	switch {
+	case *listFileName && output != "write":
+		if hasChange {
+			fmt.Println(originFilePath)
+		}
+	case output == "stdout":
+		fmt.Print(string(formattedOutput))
+	case output == "file" || output == "write":
+		if !hasChange {
+			return
+		}
+		if err := repo.WriteStaged(originFilePath, formattedOutput); err != nil {
+			log.Fatalf("failed to write fixed result to index(%s): %+v\n", originFilePath, err)
+		}
+
+		workTreeContent, err := os.ReadFile(originFilePath)
+		if err == nil && bytes.Equal(workTreeContent, stagedContent) {
+			if err := os.WriteFile(originFilePath, formattedOutput, 0o644); err != nil {
+				log.Fatalf("failed to write fixed result to file(%s): %+v\n", originFilePath, err)
+			}
+		} else {
+			log.Printf("%s has unstaged changes, only the index is fixed\n", originFilePath)
+		}
+
+		if *listFileName {
+			fmt.Println(originFilePath)
+		}
+	default:
+		log.Fatalf(`invalid output %q specified`, output)
+	}
+}
+
 func resultPostProcess(hasChange bool, originFilePath string, formattedOutput []byte) {
 	switch {
 	case hasChange && *listFileName && output != "write":
@@ -482,6 +954,16 @@ func resultPostProcess(hasChange bool, originFilePath string, formattedOutput []
 	}
 }
 
+func splitCommaSeparated(s string) []string {
+	var values []string
+	for _, value := range strings.Split(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func isTerminal(f *os.File) bool {
 	stat, err := f.Stat()
 	if err != nil {
@@ -490,6 +972,14 @@ func isTerminal(f *os.File) bool {
 	return stat.Mode()&os.ModeCharDevice != 0
 }
 
+// printDiagnostics prints diagnostics to stderr and returns true if there was at least one
+func printDiagnostics(diagnostics []reviser.Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	return len(diagnostics) > 0
+}
+
 func printDeprecations(deprecatedMessagesCh chan string) {
 	var hasDeprecations bool
 	for deprecatedMessage := range deprecatedMessagesCh {
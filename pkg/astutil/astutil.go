@@ -1,24 +1,33 @@
 package astutil
 
 import (
-	"errors"
-	"fmt"
 	"go/ast"
+	"go/parser"
+	"go/token"
 	"strings"
+	"unicode"
 
 	"golang.org/x/tools/go/packages"
 )
 
-const (
-	buildTagPrefix           = "//go:build"
-	deprecatedBuildTagPrefix = "//+build"
-)
+// compilerDirectives are directives which require the import of the package
+var compilerDirectives = []struct {
+	prefix     string
+	importPath string
+}{
+	{prefix: "//go:embed", importPath: "embed"},
+	{prefix: "//go:linkname", importPath: "unsafe"},
+}
 
 // PackageImports is map of imports with their package names
 type PackageImports map[string]string
 
 // UsesImport is for analyze if the import dependency is in use
 func UsesImport(f *ast.File, packageImports PackageImports, importPath string) bool {
+	if isDirectiveImport(f, importPath) {
+		return true
+	}
+
 	importIdentNames := make(map[string]struct{}, len(f.Imports))
 
 	var importSpec *ast.ImportSpec
@@ -63,52 +72,325 @@ func UsesImport(f *ast.File, packageImports PackageImports, importPath string) b
 	return used
 }
 
+// RenameImportUses renames the package identifier of selector expressions, like `oldName.Func()`, to newName.
+// Identifiers which are resolved to declarations inside the file(variables, params, etc.) are skipped.
+func RenameImportUses(f *ast.File, oldName, newName string) {
+	RenameImportUsesFunc(f, oldName, newName, nil)
+}
+
+// RenameImportUsesFunc is the same as RenameImportUses, but renames only selectors accepted by shouldRename.
+// Ex.: for `log.Info()` shouldRename will be called with "Info".
+func RenameImportUsesFunc(f *ast.File, oldName, newName string, shouldRename func(selector string) bool) {
+	ast.Walk(
+		visitFn(
+			func(node ast.Node) {
+				sel, ok := node.(*ast.SelectorExpr)
+				if !ok {
+					return
+				}
+				ident, ok := sel.X.(*ast.Ident)
+				if !ok || ident.Name != oldName || ident.Obj != nil {
+					return
+				}
+				if shouldRename != nil && !shouldRename(sel.Sel.Name) {
+					return
+				}
+				ident.Name = newName
+			},
+		), f,
+	)
+}
+
+// ImportUses returns selectors of the file which refer to the import named as name(ex.: `log.Info` for "log").
+// Selectors of local variables which shadow the name are skipped.
+func ImportUses(f *ast.File, name string) []*ast.SelectorExpr {
+	var uses []*ast.SelectorExpr
+	ast.Inspect(f, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == name && ident.Obj == nil {
+			uses = append(uses, sel)
+		}
+		return true
+	})
+
+	return uses
+}
+
+// IsNameDeclared checks if the name is declared anywhere in the file
+func IsNameDeclared(f *ast.File, name string) bool {
+	if f.Scope != nil && f.Scope.Lookup(name) != nil {
+		return true
+	}
+
+	var declared bool
+	ast.Inspect(f, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name == name && ident.Obj != nil {
+			declared = true
+		}
+		return !declared
+	})
+
+	return declared
+}
+
+// DirectiveImports returns import paths which are required by compiler directives of the file, but are not referenced
+// with selectors: "embed" for `//go:embed` and "unsafe" for `//go:linkname`
+func DirectiveImports(f *ast.File) []string {
+	var importPaths []string
+	for _, directive := range compilerDirectives {
+		if hasCompilerDirective(f, directive.prefix) {
+			importPaths = append(importPaths, directive.importPath)
+		}
+	}
+
+	return importPaths
+}
+
+func hasCompilerDirective(f *ast.File, prefix string) bool {
+	for _, g := range f.Comments {
+		for _, c := range g.List {
+			if c.Text == prefix || strings.HasPrefix(c.Text, prefix+" ") || strings.HasPrefix(c.Text, prefix+"\t") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isDirectiveImport(f *ast.File, importPath string) bool {
+	for _, directiveImport := range DirectiveImports(f) {
+		if directiveImport == importPath {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PackageLoadError is returned by LoadPackageDependenciesForContext together with the imports which were resolved, if the package
+// or its dependencies have errors(ex.: a file doesn't compile or a dependency is missing)
+type PackageLoadError struct {
+	Errors []packages.Error
+}
+
+func (e *PackageLoadError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+
+	return "package has errors: " + strings.Join(messages, "; ")
+}
+
 // LoadPackageDependencies will return all package's imports with it names:
 //
 //	key - package(ex.: github/pkg/errors), value - name(ex.: errors)
+//
+// Deprecated: use LoadPackageDependenciesForContext, buildTag can't describe the build configuration of the file.
 func LoadPackageDependencies(dir, buildTag string) (PackageImports, error) {
-	cfg := &packages.Config{
-		Dir:   dir,
-		Tests: true,
-		Mode:  packages.NeedName | packages.NeedImports,
-	}
-
+	var ctx BuildContext
 	if buildTag != "" {
-		cfg.BuildFlags = []string{fmt.Sprintf(`-tags=%s`, buildTag)}
+		ctx.Tags = []string{buildTag}
 	}
 
-	pkgs, err := packages.Load(cfg)
+	return LoadPackageDependenciesForContext(dir, ctx)
+}
+
+// LoadPackageDependenciesForContext is the same as LoadPackageDependencies, but packages are loaded for the build
+// configuration ctx. If the package has errors, imports which were resolved are returned with *PackageLoadError.
+func LoadPackageDependenciesForContext(dir string, ctx BuildContext) (PackageImports, error) {
+	pkgs, err := loadPackages(dir, ctx)
 	if err != nil {
 		return PackageImports{}, err
 	}
 
-	if packages.PrintErrors(pkgs) > 0 {
-		return PackageImports{}, errors.New("package has an errors")
+	return packageDependencies(pkgs)
+}
+
+// PackageDependencies is the result of LoadPackageDependenciesForContext for the package directory
+type PackageDependencies struct {
+	Imports PackageImports
+	// Err is *PackageLoadError if the package has errors
+	Err error
+}
+
+// LoadPackagesDependencies is the same as LoadPackageDependenciesForContext for the packages in pkgDirs, but they are loaded with
+// a single `go list` call from dir. All directories should belong to the module of dir. The result is grouped by the
+// package directories, directories which were not loaded are omitted.
+func LoadPackagesDependencies(dir string, pkgDirs []string, ctx BuildContext) (map[string]PackageDependencies, error) {
+	pkgs, err := loadPackages(dir, ctx, pkgDirs...)
+	if err != nil {
+		return nil, err
+	}
+
+	dirPkgs := map[string][]*packages.Package{}
+	for _, pkg := range pkgs {
+		if pkg.Dir != "" {
+			dirPkgs[pkg.Dir] = append(dirPkgs[pkg.Dir], pkg)
+		}
+	}
+
+	result := make(map[string]PackageDependencies, len(dirPkgs))
+	for pkgDir, pkgs := range dirPkgs {
+		imports, err := packageDependencies(pkgs)
+		result[pkgDir] = PackageDependencies{Imports: imports, Err: err}
 	}
 
+	return result, nil
+}
+
+func loadPackages(dir string, ctx BuildContext, patterns ...string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Dir:        dir,
+		Tests:      true,
+		Mode:       packages.NeedName | packages.NeedImports | packages.NeedFiles,
+		BuildFlags: ctx.buildFlags(),
+		Env:        ctx.env(),
+	}
+
+	return packages.Load(cfg, patterns...)
+}
+
+// packageDependencies merges imports of the packages and collects errors of the packages and their dependencies
+func packageDependencies(pkgs []*packages.Package) (PackageImports, error) {
 	result := PackageImports{}
 
 	for _, pkg := range pkgs {
 		for imprt, pkg := range pkg.Imports {
-			result[imprt] = pkg.Name
+			if pkg.Name != "" {
+				result[imprt] = pkg.Name
+			}
+		}
+	}
+
+	var loadErrs []packages.Error
+	seen := map[string]struct{}{}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			if _, ok := seen[err.Error()]; ok {
+				continue
+			}
+			seen[err.Error()] = struct{}{}
+			loadErrs = append(loadErrs, err)
 		}
+	})
+	if len(loadErrs) > 0 {
+		return result, &PackageLoadError{Errors: loadErrs}
 	}
 
 	return result, nil
 }
 
-// ParseBuildTag parse `//+build ...` or `//go:build ` on a first line of *ast.File
-func ParseBuildTag(f *ast.File) string {
-	for _, g := range f.Comments {
-		for _, c := range g.List {
-			if !(strings.HasPrefix(c.Text, buildTagPrefix) || strings.HasPrefix(c.Text, deprecatedBuildTagPrefix)) {
-				continue
+// GuessPackageName returns the package name which is assumed from the import path, if the package can't be loaded:
+// the version suffix is skipped, "go-" prefix and everything after the first char which is not allowed in identifiers
+// are cut. Ex.: "github.com/go-pg/pg/v9" => "pg", "gopkg.in/yaml.v3" => "yaml", "github.com/nats-io/go-nats" => "nats"
+func GuessPackageName(importPath string) string {
+	elements := strings.Split(importPath, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && isVersionSuffix(name) {
+		name = elements[len(elements)-2]
+	}
+
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexFunc(name, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+	}); i >= 0 {
+		name = name[:i]
+	}
+
+	return name
+}
+
+func isVersionSuffix(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+
+	for _, r := range s[1:] {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PackageExports is map of packages with their exported names
+type PackageExports map[string]map[string]struct{}
+
+// LoadPackageExports will return exported top-level names of the packages in the build configuration ctx:
+//
+//	key - package(ex.: log), value - set of names(ex.: Println, Logger...)
+//
+// Packages which can't be loaded are skipped.
+func LoadPackageExports(dir string, ctx BuildContext, importPaths ...string) (PackageExports, error) {
+	cfg := &packages.Config{
+		Dir:        dir,
+		Mode:       packages.NeedName | packages.NeedFiles,
+		BuildFlags: ctx.buildFlags(),
+		Env:        ctx.env(),
+	}
+
+	pkgs, err := packages.Load(cfg, importPaths...)
+	if err != nil {
+		return PackageExports{}, err
+	}
+
+	result := PackageExports{}
+	fset := token.NewFileSet()
+	for _, pkg := range pkgs {
+		result[pkg.PkgPath] = filesExports(fset, pkg.GoFiles)
+	}
+
+	return result, nil
+}
+
+func filesExports(fset *token.FileSet, fileNames []string) map[string]struct{} {
+	names := map[string]struct{}{}
+	for _, fileName := range fileNames {
+		f, err := parser.ParseFile(fset, fileName, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for name := range exportedNames(f) {
+			names[name] = struct{}{}
+		}
+	}
+
+	return names
+}
+
+func exportedNames(f *ast.File) map[string]struct{} {
+	names := map[string]struct{}{}
+	for _, decl := range f.Decls {
+		switch dd := decl.(type) {
+		case *ast.FuncDecl:
+			if dd.Recv == nil && dd.Name.IsExported() {
+				names[dd.Name.Name] = struct{}{}
+			}
+		case *ast.GenDecl:
+			for _, spec := range dd.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						names[s.Name.Name] = struct{}{}
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							names[name.Name] = struct{}{}
+						}
+					}
+				}
 			}
-			return strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(c.Text, buildTagPrefix), deprecatedBuildTagPrefix))
 		}
 	}
 
-	return ""
+	return names
 }
 
 type visitFn func(node ast.Node)
@@ -1,8 +1,10 @@
 package astutil
 
 import (
+	"bytes"
 	"fmt"
 	"go/parser"
+	"go/printer"
 	"go/token"
 	"testing"
 
@@ -187,7 +189,7 @@ func TestLoadPackageDeps(t *testing.T) {
 			},
 			want: map[string]string{
 				"fmt":                     "fmt",
-				"golang.org/x/exp/slices": "slices",
+				"golang.org/x/mod/semver": "semver",
 			},
 			wantErr: false,
 		},
@@ -199,7 +201,7 @@ func TestLoadPackageDeps(t *testing.T) {
 			},
 			want: map[string]string{
 				"fmt":                     "fmt",
-				"golang.org/x/exp/slices": "slices",
+				"golang.org/x/mod/semver": "semver",
 			},
 			wantErr: false,
 		},
@@ -218,7 +220,12 @@ func TestLoadPackageDeps(t *testing.T) {
 			)
 			require.NoError(t, err)
 
-			got, err := LoadPackageDependencies(tt.args.dir, ParseBuildTag(f))
+			expr, err := ParseBuildConstraint(f)
+			require.NoError(t, err)
+			ctx, ok := DefaultBuildContext().ForFile(expr)
+			require.True(t, ok)
+
+			got, err := LoadPackageDependenciesForContext(tt.args.dir, ctx)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -226,6 +233,148 @@ func TestLoadPackageDeps(t *testing.T) {
 
 			assert.NoError(t, err)
 			assert.EqualValues(t, tt.want, got)
+
+			got, err = LoadPackageDependencies(tt.args.dir, ParseBuildTag(f))
+			assert.NoError(t, err)
+			assert.EqualValues(t, tt.want, got)
 		})
 	}
 }
+
+func TestDirectiveImports(t *testing.T) {
+	t.Parallel()
+
+	fileData := `package main
+
+import "fmt"
+
+//go:embed static/*
+var static embed.FS
+
+// go:linkname is not a directive with a space
+//go:linknamed nanotime runtime.nanotime
+func nanotime() int64
+
+func main() {
+	fmt.Println(static)
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "main.go", []byte(fileData), parser.ParseComments)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"embed"}, DirectiveImports(f))
+	assert.True(t, UsesImport(f, PackageImports{}, "embed"))
+	assert.False(t, UsesImport(f, PackageImports{}, "unsafe"))
+}
+
+func TestGuessPackageName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		importPath string
+		want       string
+	}{
+		{importPath: "fmt", want: "fmt"},
+		{importPath: "github.com/pkg/errors", want: "errors"},
+		{importPath: "github.com/go-pg/pg/v9", want: "pg"},
+		{importPath: "gopkg.in/yaml.v3", want: "yaml"},
+		{importPath: "github.com/nats-io/go-nats", want: "nats"},
+		{importPath: "github.com/mattn/go-sqlite3", want: "sqlite3"},
+		{importPath: "github.com/go-sql-driver/mysql", want: "mysql"},
+		{importPath: "github.com/opentracing/opentracing-go", want: "opentracing"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.importPath, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, GuessPackageName(tt.importPath))
+		})
+	}
+}
+
+func TestLoadPackageExports(t *testing.T) {
+	t.Parallel()
+
+	got, err := LoadPackageExports("./testdata/", DefaultBuildContext(), "errors", "strings")
+	require.NoError(t, err)
+
+	assert.Contains(t, got["errors"], "New")
+	assert.Contains(t, got["errors"], "ErrUnsupported")
+	assert.NotContains(t, got["errors"], "errorString")
+	assert.Contains(t, got["strings"], "Builder")
+	assert.NotContains(t, got["strings"], "New")
+}
+
+func TestImportUses(t *testing.T) {
+	t.Parallel()
+
+	fileData := `package main
+
+import (
+	"log"
+)
+
+func main() {
+	log.Println("A")
+	log.Info("A")
+	func(log struct{ Info int }) {
+		_ = log.Info
+	}(struct{ Info int }{})
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", []byte(fileData), parser.ParseComments)
+	require.NoError(t, err)
+
+	var selectors []string
+	for _, sel := range ImportUses(f, "log") {
+		selectors = append(selectors, sel.Sel.Name)
+	}
+
+	assert.Equal(t, []string{"Println", "Info"}, selectors)
+}
+
+func TestRenameImportUsesFunc(t *testing.T) {
+	t.Parallel()
+
+	fileData := `package main
+
+import (
+	"log"
+)
+
+func main() {
+	log.Println("A")
+	log.Info("A")
+	func(log struct{ Info int }) {
+		_ = log.Info
+	}(struct{ Info int }{})
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", []byte(fileData), parser.ParseComments)
+	require.NoError(t, err)
+
+	RenameImportUsesFunc(f, "log", "customlog", func(selector string) bool {
+		return selector == "Info"
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, printer.Fprint(&buf, fset, f))
+	assert.Equal(t, `package main
+
+import (
+	"log"
+)
+
+func main() {
+	log.Println("A")
+	customlog.Info("A")
+	func(log struct{ Info int }) {
+		_ = log.Info
+	}(struct{ Info int }{})
+}
+`, buf.String())
+}
@@ -0,0 +1,294 @@
+package astutil
+
+import (
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"go/types"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	buildTagPrefix           = "//go:build"
+	deprecatedBuildTagPrefix = "//+build"
+)
+
+// maxFileTags limits the number of custom tags of the file constraint which are tried to find the tags required to
+// build the file
+const maxFileTags = 10
+
+var (
+	knownOS = map[string]struct{}{
+		"aix": {}, "android": {}, "darwin": {}, "dragonfly": {}, "freebsd": {}, "hurd": {}, "illumos": {}, "ios": {},
+		"js": {}, "linux": {}, "nacl": {}, "netbsd": {}, "openbsd": {}, "plan9": {}, "solaris": {}, "wasip1": {},
+		"windows": {}, "zos": {},
+	}
+	unixOS = map[string]struct{}{
+		"aix": {}, "android": {}, "darwin": {}, "dragonfly": {}, "freebsd": {}, "hurd": {}, "illumos": {}, "ios": {},
+		"linux": {}, "netbsd": {}, "openbsd": {}, "solaris": {},
+	}
+	knownArch = map[string]struct{}{
+		"386": {}, "amd64": {}, "amd64p32": {}, "arm": {}, "armbe": {}, "arm64": {}, "arm64be": {}, "loong64": {},
+		"mips": {}, "mipsle": {}, "mips64": {}, "mips64le": {}, "mips64p32": {}, "mips64p32le": {}, "ppc": {},
+		"ppc64": {}, "ppc64le": {}, "riscv": {}, "riscv64": {}, "s390": {}, "s390x": {}, "sparc": {}, "sparc64": {},
+		"wasm": {},
+	}
+)
+
+// BuildContext is a build configuration of the package: target OS, architecture and custom build tags
+type BuildContext struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+// DefaultBuildContext returns the build configuration of the current environment($GOOS and $GOARCH)
+func DefaultBuildContext() BuildContext {
+	return BuildContext{GOOS: build.Default.GOOS, GOARCH: build.Default.GOARCH}
+}
+
+// BuildContexts returns build configurations for all combinations of goos and goarch with the tags. Current $GOOS or
+// $GOARCH is used if the list is empty.
+func BuildContexts(goos, goarch, tags []string) []BuildContext {
+	if len(goos) == 0 {
+		goos = []string{build.Default.GOOS}
+	}
+	if len(goarch) == 0 {
+		goarch = []string{build.Default.GOARCH}
+	}
+
+	contexts := make([]BuildContext, 0, len(goos)*len(goarch))
+	for _, targetOS := range goos {
+		for _, targetArch := range goarch {
+			contexts = append(contexts, BuildContext{GOOS: targetOS, GOARCH: targetArch, Tags: tags})
+		}
+	}
+
+	return contexts
+}
+
+// ParseBuildConstraint parses `//go:build ...` or `// +build ...` lines above the package clause of *ast.File.
+// `//go:build` line takes precedence. Returns nil if the file has no constraint.
+func ParseBuildConstraint(f *ast.File) (constraint.Expr, error) {
+	var plusBuildExpr constraint.Expr
+	for _, g := range f.Comments {
+		if g.Pos() >= f.Package {
+			break
+		}
+
+		for _, c := range g.List {
+			switch {
+			case constraint.IsGoBuild(c.Text):
+				return constraint.Parse(c.Text)
+			case constraint.IsPlusBuild(c.Text):
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					return nil, err
+				}
+				if plusBuildExpr == nil {
+					plusBuildExpr = expr
+				} else {
+					plusBuildExpr = &constraint.AndExpr{X: plusBuildExpr, Y: expr}
+				}
+			}
+		}
+	}
+
+	return plusBuildExpr, nil
+}
+
+// ParseBuildTag parse `//+build ...` or `//go:build ` on a first line of *ast.File
+//
+// Deprecated: use ParseBuildConstraint.
+func ParseBuildTag(f *ast.File) string {
+	for _, g := range f.Comments {
+		for _, c := range g.List {
+			if !(strings.HasPrefix(c.Text, buildTagPrefix) || strings.HasPrefix(c.Text, deprecatedBuildTagPrefix)) {
+				continue
+			}
+			return strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(c.Text, buildTagPrefix), deprecatedBuildTagPrefix))
+		}
+	}
+
+	return ""
+}
+
+// Matches reports whether the file with the constraint is built in the context. Nil constraint matches any context.
+func (c BuildContext) Matches(expr constraint.Expr) bool {
+	return expr == nil || expr.Eval(c.hasTag)
+}
+
+// ForFile returns the context with the custom tags which are required to build the file with the constraint(ex.:
+// "tools" for `//go:build tools`). Returns false if the file is not built in the context with any custom tags.
+func (c BuildContext) ForFile(expr constraint.Expr) (BuildContext, bool) {
+	if c.Matches(expr) {
+		return c, true
+	}
+
+	var fileTags []string
+	for _, tag := range constraintTags(expr) {
+		if !c.isKnownTag(tag) && !slices.Contains(fileTags, tag) {
+			fileTags = append(fileTags, tag)
+		}
+	}
+	if len(fileTags) > maxFileTags {
+		fileTags = fileTags[:maxFileTags]
+	}
+
+	var (
+		best  BuildContext
+		found bool
+	)
+	for mask := 1; mask < 1<<len(fileTags); mask++ {
+		ctx := c.withTags(fileTags, mask)
+		if ctx.Matches(expr) && (!found || len(ctx.Tags) < len(best.Tags)) {
+			best, found = ctx, true
+		}
+	}
+	if !found {
+		return c, false
+	}
+
+	return best, true
+}
+
+// withTags returns the context with the tags selected by the bit mask
+func (c BuildContext) withTags(tags []string, mask int) BuildContext {
+	ctx := BuildContext{GOOS: c.GOOS, GOARCH: c.GOARCH, Tags: append([]string{}, c.Tags...)}
+	for i, tag := range tags {
+		if mask&(1<<i) != 0 {
+			ctx.Tags = append(ctx.Tags, tag)
+		}
+	}
+
+	return ctx
+}
+
+// Importer returns the importer which fails on std packages without Go files for the context(ex.: "syscall/js" for
+// linux). Other packages are imported with imp as is.
+func (c BuildContext) Importer(imp types.ImporterFrom) types.ImporterFrom {
+	return &contextImporter{context: c, importer: imp}
+}
+
+func (c BuildContext) hasTag(tag string) bool {
+	switch tag {
+	case c.GOOS, c.GOARCH, "gc":
+		return true
+	case "unix":
+		_, ok := unixOS[c.GOOS]
+		return ok
+	case "linux":
+		return c.GOOS == "android"
+	case "darwin":
+		return c.GOOS == "ios"
+	case "solaris":
+		return c.GOOS == "illumos"
+	case "cgo":
+		if slices.Contains(c.Tags, tag) {
+			return true
+		}
+		return build.Default.CgoEnabled && c.GOOS == build.Default.GOOS && c.GOARCH == build.Default.GOARCH
+	}
+
+	return slices.Contains(c.Tags, tag) || slices.Contains(build.Default.ReleaseTags, tag)
+}
+
+// isKnownTag reports whether the tag is set by the toolchain(OS, architecture, compiler, cgo or Go version)
+func (c BuildContext) isKnownTag(tag string) bool {
+	if _, ok := knownOS[tag]; ok {
+		return true
+	}
+	if _, ok := knownArch[tag]; ok {
+		return true
+	}
+
+	switch tag {
+	case "unix", "cgo", "gc", "gccgo":
+		return true
+	}
+
+	return strings.HasPrefix(tag, "go1.") || slices.Contains(c.Tags, tag)
+}
+
+// String returns the build configuration as "GOOS/GOARCH" with sorted tags(ex.: "linux/amd64 tags=integration,tools").
+// Equal configurations have equal strings, so it can be used as a key.
+func (c BuildContext) String() string {
+	s := c.GOOS + "/" + c.GOARCH
+	if len(c.Tags) == 0 {
+		return s
+	}
+
+	tags := slices.Clone(c.Tags)
+	slices.Sort(tags)
+
+	return s + " tags=" + strings.Join(slices.Compact(tags), ",")
+}
+
+func (c BuildContext) buildFlags() []string {
+	if len(c.Tags) == 0 {
+		return nil
+	}
+
+	return []string{"-tags=" + strings.Join(c.Tags, ",")}
+}
+
+func (c BuildContext) env() []string {
+	if c.GOOS == "" && c.GOARCH == "" {
+		return nil
+	}
+
+	env := os.Environ()
+	if c.GOOS != "" {
+		env = append(env, "GOOS="+c.GOOS)
+	}
+	if c.GOARCH != "" {
+		env = append(env, "GOARCH="+c.GOARCH)
+	}
+
+	return env
+}
+
+type contextImporter struct {
+	context  BuildContext
+	importer types.ImporterFrom
+}
+
+func (i *contextImporter) Import(path string) (*types.Package, error) {
+	return i.ImportFrom(path, "", 0)
+}
+
+func (i *contextImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if isStdPackage(path) {
+		ctxt := build.Default
+		ctxt.GOOS = i.context.GOOS
+		ctxt.GOARCH = i.context.GOARCH
+		ctxt.BuildTags = i.context.Tags
+		ctxt.CgoEnabled = i.context.hasTag("cgo")
+		if _, err := ctxt.Import(path, "", 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return i.importer.ImportFrom(path, dir, mode)
+}
+
+func isStdPackage(path string) bool {
+	return !strings.Contains(strings.Split(path, "/")[0], ".") && path != "C"
+}
+
+func constraintTags(expr constraint.Expr) []string {
+	switch e := expr.(type) {
+	case *constraint.TagExpr:
+		return []string{e.Tag}
+	case *constraint.NotExpr:
+		return constraintTags(e.X)
+	case *constraint.AndExpr:
+		return append(constraintTags(e.X), constraintTags(e.Y)...)
+	case *constraint.OrExpr:
+		return append(constraintTags(e.X), constraintTags(e.Y)...)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package astutil
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBuildConstraint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		fileData string
+		want     string
+	}{
+		{
+			name:     "go:build",
+			fileData: "//go:build linux && !cgo\n\npackage main\n",
+			want:     "linux && !cgo",
+		},
+		{
+			name:     "go:build takes precedence",
+			fileData: "//go:build tools\n// +build ignore\n\npackage main\n",
+			want:     "tools",
+		},
+		{
+			name:     "plus build lines",
+			fileData: "// +build linux darwin\n// +build amd64\n\npackage main\n",
+			want:     "(linux || darwin) && amd64",
+		},
+		{
+			name:     "constraint after package clause is ignored",
+			fileData: "package main\n\n//go:build linux\n",
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := parser.ParseFile(token.NewFileSet(), "main.go", tt.fileData, parser.ParseComments)
+			require.NoError(t, err)
+
+			got, err := ParseBuildConstraint(f)
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestBuildContext_ForFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		ctx        BuildContext
+		constraint string
+		wantTags   []string
+		wantOK     bool
+	}{
+		{
+			name:       "matching OS without cgo for cross build",
+			ctx:        BuildContext{GOOS: "linux", GOARCH: "riscv64"},
+			constraint: "//go:build linux && !cgo",
+			wantOK:     true,
+		},
+		{
+			name:       "not matching OS",
+			ctx:        BuildContext{GOOS: "windows", GOARCH: "amd64"},
+			constraint: "//go:build linux",
+			wantOK:     false,
+		},
+		{
+			name:       "unix",
+			ctx:        BuildContext{GOOS: "darwin", GOARCH: "arm64"},
+			constraint: "//go:build unix",
+			wantOK:     true,
+		},
+		{
+			name:       "custom tag is added",
+			ctx:        BuildContext{GOOS: "linux", GOARCH: "amd64", Tags: []string{"integration"}},
+			constraint: "//go:build tools",
+			wantTags:   []string{"integration", "tools"},
+			wantOK:     true,
+		},
+		{
+			name:       "minimal set of custom tags",
+			ctx:        BuildContext{GOOS: "linux", GOARCH: "amd64"},
+			constraint: "//go:build (a && b) || c",
+			wantTags:   []string{"c"},
+			wantOK:     true,
+		},
+		{
+			name:       "negated custom tag",
+			ctx:        BuildContext{GOOS: "linux", GOARCH: "amd64", Tags: []string{"ignore"}},
+			constraint: "//go:build !ignore",
+			wantOK:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := parser.ParseFile(token.NewFileSet(), "main.go", tt.constraint+"\n\npackage main\n", parser.ParseComments)
+			require.NoError(t, err)
+			expr, err := ParseBuildConstraint(f)
+			require.NoError(t, err)
+
+			got, ok := tt.ctx.ForFile(expr)
+			assert.Equal(t, tt.wantOK, ok)
+			if !tt.wantOK {
+				return
+			}
+			if tt.wantTags == nil {
+				tt.wantTags = tt.ctx.Tags
+			}
+			assert.Equal(t, tt.wantTags, got.Tags)
+		})
+	}
+}
+
+func TestBuildContext_Importer(t *testing.T) {
+	t.Parallel()
+
+	imp := BuildContext{GOOS: "linux", GOARCH: "amd64"}.Importer(NewPackageImporter(PackageImports{"strings": "strings", "syscall/js": "js"}, nil))
+
+	_, err := imp.Import("strings")
+	assert.NoError(t, err)
+
+	_, err = imp.Import("syscall/js")
+	assert.Error(t, err)
+}
+
+func TestBuildContext_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "linux/amd64", BuildContext{GOOS: "linux", GOARCH: "amd64"}.String())
+	assert.Equal(
+		t,
+		BuildContext{GOOS: "linux", GOARCH: "amd64", Tags: []string{"b", "a", "b"}}.String(),
+		BuildContext{GOOS: "linux", GOARCH: "amd64", Tags: []string{"a", "b"}}.String(),
+	)
+	assert.Equal(t, "linux/amd64 tags=a,b", BuildContext{GOOS: "linux", GOARCH: "amd64", Tags: []string{"b", "a"}}.String())
+}
@@ -0,0 +1,94 @@
+package astutil
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const deprecatedPrefix = "Deprecated:"
+
+// PackageDeprecations is map of deprecated packages with their deprecation messages
+type PackageDeprecations map[string]string
+
+// LoadPackageDeprecations reads the package documentation of the imports without running `go` command and returns
+// deprecated packages:
+//
+//	key - package(ex.: io/ioutil), value - deprecation message(ex.: As of Go 1.16, ...)
+//
+// Sources are looked up in vendor/ directory of the module which contains dir, in $GOROOT for std packages and in
+// the module cache for dependencies(see ModuleCacheResolver). Packages which can't be found are skipped.
+func LoadPackageDeprecations(dir string, importPaths []string) (PackageDeprecations, error) {
+	pkgDirs, err := ModuleCacheResolver{}.packageDirs(dir, importPaths)
+
+	if root, _ := moduleRoot(dir); root != "" {
+		vendorDir := filepath.Join(root, "vendor")
+		for _, importPath := range importPaths {
+			if isStdPackage(importPath) {
+				continue
+			}
+			pkgDir := filepath.Join(vendorDir, filepath.FromSlash(importPath))
+			if fi, err := os.Stat(pkgDir); err == nil && fi.IsDir() {
+				pkgDirs[importPath] = pkgDir
+			}
+		}
+	}
+
+	result := PackageDeprecations{}
+	for importPath, pkgDir := range pkgDirs {
+		if message, ok := readPackageDeprecation(pkgDir); ok {
+			result[importPath] = message
+		}
+	}
+
+	return result, err
+}
+
+// readPackageDeprecation returns the message of `Deprecated:` paragraph of the package documentation in dir
+func readPackageDeprecation(dir string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".go") || strings.HasSuffix(fileName, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, fileName), nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil || f.Name.Name == "main" || f.Name.Name == "documentation" {
+			continue
+		}
+
+		if message, ok := DeprecationMessage(f.Doc); ok {
+			return message, true
+		}
+	}
+
+	return "", false
+}
+
+// DeprecationMessage returns the text of the paragraph which starts with `Deprecated:` in the doc comment. Lines of
+// the paragraph are joined with spaces.
+func DeprecationMessage(doc *ast.CommentGroup) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+
+	for _, paragraph := range strings.Split(doc.Text(), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if !strings.HasPrefix(paragraph, deprecatedPrefix) {
+			continue
+		}
+
+		return strings.Join(strings.Fields(strings.TrimPrefix(paragraph, deprecatedPrefix)), " "), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,106 @@
+package astutil
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecationMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		src    string
+		want   string
+		wantOk bool
+	}{
+		{
+			name: "deprecated paragraph",
+			src: `// Package old does things.
+//
+// Deprecated: use
+// example.com/new instead.
+package old
+`,
+			want:   "use example.com/new instead.",
+			wantOk: true,
+		},
+		{
+			name:   "deprecated only",
+			src:    "// Deprecated: do not use.\npackage old\n",
+			want:   "do not use.",
+			wantOk: true,
+		},
+		{
+			name: "deprecated inside paragraph",
+			src: `// Package old does things.
+// Deprecated: is not at the start of the paragraph.
+package old
+`,
+		},
+		{
+			name: "no doc",
+			src:  "package old\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := parser.ParseFile(token.NewFileSet(), "old.go", tt.src, parser.PackageClauseOnly|parser.ParseComments)
+			require.NoError(t, err)
+
+			got, ok := DeprecationMessage(f.Doc)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadPackageDeprecations(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), `module example.com/project
+
+go 1.21
+
+require (
+	example.com/replaced v1.0.0
+	example.com/vendored v1.0.0
+)
+
+replace example.com/replaced => ./local/replaced
+`)
+	writeTestFile(t, filepath.Join(root, "local", "replaced", "old", "doc.go"), "// Package old.\n//\n// Deprecated: use example.com/replaced/new.\npackage old\n")
+	writeTestFile(t, filepath.Join(root, "local", "replaced", "old", "old_test.go"), "// Deprecated: ignored.\npackage old\n")
+	writeTestFile(t, filepath.Join(root, "local", "replaced", "new", "new.go"), "// Package new.\npackage new\n")
+	writeTestFile(t, filepath.Join(root, "vendor", "example.com", "vendored", "vendored.go"), "// Deprecated: frozen.\npackage vendored\n")
+	writeTestFile(t, filepath.Join(root, "internal", "lib", "lib.go"), "// Deprecated: internal.\npackage lib\n")
+
+	got, err := LoadPackageDeprecations(root, []string{
+		"fmt",
+		"io/ioutil",
+		"example.com/replaced/old",
+		"example.com/replaced/new",
+		"example.com/vendored",
+		"example.com/project/internal/lib",
+		"example.com/unknown",
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, got, "io/ioutil")
+	delete(got, "io/ioutil")
+	assert.Equal(t, PackageDeprecations{
+		"example.com/replaced/old":         "use example.com/replaced/new.",
+		"example.com/vendored":             "frozen.",
+		"example.com/project/internal/lib": "internal.",
+	}, got)
+}
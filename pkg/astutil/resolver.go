@@ -0,0 +1,487 @@
+package astutil
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+
+	goModule "github.com/incu6us/goimports-reviser/v3/pkg/module"
+)
+
+// PackageNameResolver resolves names of the packages imported from the directory dir. Names which were resolved are
+// returned even if an error occurred. Import paths which can't be resolved are omitted from the result.
+type PackageNameResolver interface {
+	ResolvePackageNames(dir string, importPaths []string) (PackageImports, error)
+}
+
+// PackageExportsResolver resolves exported top-level names of the packages imported from the directory dir. Packages
+// which can't be resolved are omitted from the result.
+type PackageExportsResolver interface {
+	ResolvePackageExports(dir string, importPaths []string) (PackageExports, error)
+}
+
+// BuildContextResolver is implemented by resolvers which results depend on the build configuration. WithBuildContext
+// returns the resolver for the build configuration ctx.
+type BuildContextResolver interface {
+	WithBuildContext(ctx BuildContext) PackageNameResolver
+}
+
+// GoListResolver resolves package names with `go list` for the build configuration Context
+type GoListResolver struct {
+	Context BuildContext
+}
+
+// ResolvePackageNames loads imports of the package in dir with `go list`
+func (r GoListResolver) ResolvePackageNames(dir string, importPaths []string) (PackageImports, error) {
+	packageImports, err := LoadPackageDependenciesForContext(dir, r.Context)
+
+	return packageImports.filter(importPaths), err
+}
+
+// ResolvePackageExports loads exported names of the packages with `go list`
+func (r GoListResolver) ResolvePackageExports(dir string, importPaths []string) (PackageExports, error) {
+	return LoadPackageExports(dir, r.Context, importPaths...)
+}
+
+// WithBuildContext returns the resolver for the build configuration ctx
+func (r GoListResolver) WithBuildContext(ctx BuildContext) PackageNameResolver {
+	return GoListResolver{Context: ctx}
+}
+
+// String describes the resolver, ex.: "golist" or "golist(linux/amd64)"
+func (r GoListResolver) String() string {
+	if r.Context.GOOS == "" && r.Context.GOARCH == "" && len(r.Context.Tags) == 0 {
+		return "golist"
+	}
+
+	return fmt.Sprintf("golist(%s)", r.Context)
+}
+
+// VendorResolver resolves package names from the package clause of the sources in vendor/ directory of the module
+type VendorResolver struct{}
+
+// String describes the resolver
+func (r VendorResolver) String() string {
+	return "vendor"
+}
+
+// ResolvePackageNames reads package names of the vendored packages of the module which contains dir
+func (r VendorResolver) ResolvePackageNames(dir string, importPaths []string) (PackageImports, error) {
+	root, err := moduleRoot(dir)
+	if err != nil || root == "" {
+		return PackageImports{}, err
+	}
+
+	vendorDir := filepath.Join(root, "vendor")
+	if fi, err := os.Stat(vendorDir); err != nil || !fi.IsDir() {
+		return PackageImports{}, nil
+	}
+
+	result := PackageImports{}
+	for _, importPath := range importPaths {
+		if name, err := readPackageName(filepath.Join(vendorDir, filepath.FromSlash(importPath))); err == nil {
+			result[importPath] = name
+		}
+	}
+
+	return result, nil
+}
+
+// ResolvePackageExports reads exported names of the vendored packages of the module which contains dir
+func (r VendorResolver) ResolvePackageExports(dir string, importPaths []string) (PackageExports, error) {
+	root, err := moduleRoot(dir)
+	if err != nil || root == "" {
+		return PackageExports{}, err
+	}
+
+	result := PackageExports{}
+	for _, importPath := range importPaths {
+		if isStdPackage(importPath) {
+			continue
+		}
+		if names, err := readPackageExports(filepath.Join(root, "vendor", filepath.FromSlash(importPath))); err == nil {
+			result[importPath] = names
+		}
+	}
+
+	return result, nil
+}
+
+// ModuleCacheResolver resolves package names from the package clause of the sources: std packages are read from
+// $GOROOT, packages of the main module from its directory and dependencies from the module cache(or the replacement
+// directory) with the versions required by go.mod. ModCacheDir is $GOMODCACHE by default.
+type ModuleCacheResolver struct {
+	ModCacheDir string
+}
+
+// String describes the resolver, ex.: "modcache" or "modcache(/tmp/mod)"
+func (r ModuleCacheResolver) String() string {
+	if r.ModCacheDir == "" {
+		return "modcache"
+	}
+
+	return fmt.Sprintf("modcache(%s)", r.ModCacheDir)
+}
+
+// ResolvePackageNames reads package names of the packages imported by the module which contains dir
+func (r ModuleCacheResolver) ResolvePackageNames(dir string, importPaths []string) (PackageImports, error) {
+	pkgDirs, err := r.packageDirs(dir, importPaths)
+
+	result := PackageImports{}
+	for importPath, pkgDir := range pkgDirs {
+		if name, err := readPackageName(pkgDir); err == nil {
+			result[importPath] = name
+		}
+	}
+
+	return result, err
+}
+
+// ResolvePackageExports reads exported names of the packages imported by the module which contains dir
+func (r ModuleCacheResolver) ResolvePackageExports(dir string, importPaths []string) (PackageExports, error) {
+	pkgDirs, err := r.packageDirs(dir, importPaths)
+
+	result := PackageExports{}
+	for importPath, pkgDir := range pkgDirs {
+		if names, err := readPackageExports(pkgDir); err == nil {
+			result[importPath] = names
+		}
+	}
+
+	return result, err
+}
+
+// packageDirs returns directories with the sources of the packages imported by the module which contains dir.
+// Directories are not checked for existence. Import paths which don't belong to std or any required module are omitted.
+func (r ModuleCacheResolver) packageDirs(dir string, importPaths []string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, importPath := range importPaths {
+		if isStdPackage(importPath) {
+			result[importPath] = filepath.Join(build.Default.GOROOT, "src", filepath.FromSlash(importPath))
+		}
+	}
+
+	root, err := moduleRoot(dir)
+	if err != nil || root == "" {
+		return result, err
+	}
+
+	goModFile := filepath.Join(root, "go.mod")
+	data, err := os.ReadFile(goModFile)
+	if err != nil {
+		return result, err
+	}
+
+	modFile, err := modfile.Parse(goModFile, data, nil)
+	if err != nil {
+		return result, err
+	}
+
+	modules := map[string]string{}
+	if modFile.Module != nil {
+		modules[modFile.Module.Mod.Path] = root
+	}
+	for _, require := range modFile.Require {
+		if modDir, err := r.moduleDir(require.Mod); err == nil {
+			modules[require.Mod.Path] = modDir
+		}
+	}
+	for _, replace := range modFile.Replace {
+		if _, ok := modules[replace.Old.Path]; !ok {
+			continue
+		}
+		if modfile.IsDirectoryPath(replace.New.Path) {
+			modules[replace.Old.Path] = filepath.Join(root, filepath.FromSlash(replace.New.Path))
+		} else if modDir, err := r.moduleDir(replace.New); err == nil {
+			modules[replace.Old.Path] = modDir
+		}
+	}
+
+	for _, importPath := range importPaths {
+		if _, ok := result[importPath]; ok {
+			continue
+		}
+
+		modPath, modDir := longestModulePrefix(modules, importPath)
+		if modPath == "" {
+			continue
+		}
+
+		result[importPath] = filepath.Join(modDir, filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(importPath, modPath), "/")))
+	}
+
+	return result, nil
+}
+
+func (r ModuleCacheResolver) moduleDir(mod module.Version) (string, error) {
+	modCacheDir := r.ModCacheDir
+	if modCacheDir == "" {
+		modCacheDir = defaultModCacheDir()
+	}
+
+	escapedPath, err := module.EscapePath(mod.Path)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err := module.EscapeVersion(mod.Version)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(modCacheDir, filepath.FromSlash(escapedPath)+"@"+escapedVersion), nil
+}
+
+// HeuristicResolver assumes package names from the import paths(see GuessPackageName). It resolves every import path,
+// so it should be the last one in ChainResolver.
+type HeuristicResolver struct{}
+
+// String describes the resolver
+func (r HeuristicResolver) String() string {
+	return "heuristic"
+}
+
+// ResolvePackageNames assumes names of the packages from their import paths
+func (r HeuristicResolver) ResolvePackageNames(_ string, importPaths []string) (PackageImports, error) {
+	result := make(PackageImports, len(importPaths))
+	for _, importPath := range importPaths {
+		result[importPath] = GuessPackageName(importPath)
+	}
+
+	return result, nil
+}
+
+// ChainResolver resolves package names with every resolver in order. Each next resolver gets only import paths which
+// were not resolved by the previous ones. Errors are returned only if some import paths were not resolved at all.
+type ChainResolver []PackageNameResolver
+
+// String describes resolvers of the chain separated by comma, ex.: "vendor,modcache,heuristic". Resolvers which don't
+// implement fmt.Stringer are described by their type.
+func (c ChainResolver) String() string {
+	names := make([]string, 0, len(c))
+	for _, resolver := range c {
+		names = append(names, ResolverString(resolver))
+	}
+
+	return strings.Join(names, ",")
+}
+
+// ResolvePackageNames resolves package names with the chain of resolvers
+func (c ChainResolver) ResolvePackageNames(dir string, importPaths []string) (PackageImports, error) {
+	result := PackageImports{}
+	unresolved := importPaths
+
+	var errs []error
+	for _, resolver := range c {
+		if len(unresolved) == 0 {
+			break
+		}
+
+		packageImports, err := resolver.ResolvePackageNames(dir, unresolved)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		var left []string
+		for _, importPath := range unresolved {
+			if name, ok := packageImports[importPath]; ok {
+				result[importPath] = name
+			} else {
+				left = append(left, importPath)
+			}
+		}
+		unresolved = left
+	}
+
+	if len(unresolved) > 0 {
+		errs = append(errs, fmt.Errorf("failed to resolve package names: %s", strings.Join(unresolved, ", ")))
+		return result, errors.Join(errs...)
+	}
+
+	return result, nil
+}
+
+// ResolvePackageExports resolves exported names with every resolver of the chain which implements
+// PackageExportsResolver in order. Each next resolver gets only packages which were not resolved by the previous ones.
+// Packages which were not resolved at all are omitted without an error: their exports are unknown.
+func (c ChainResolver) ResolvePackageExports(dir string, importPaths []string) (PackageExports, error) {
+	result := PackageExports{}
+	unresolved := importPaths
+
+	var errs []error
+	for _, resolver := range c {
+		exportsResolver, ok := resolver.(PackageExportsResolver)
+		if !ok || len(unresolved) == 0 {
+			continue
+		}
+
+		exports, err := exportsResolver.ResolvePackageExports(dir, unresolved)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		var left []string
+		for _, importPath := range unresolved {
+			if names, ok := exports[importPath]; ok {
+				result[importPath] = names
+			} else {
+				left = append(left, importPath)
+			}
+		}
+		unresolved = left
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// WithBuildContext returns the chain where every resolver which implements BuildContextResolver is replaced with the
+// resolver for the build configuration ctx
+func (c ChainResolver) WithBuildContext(ctx BuildContext) PackageNameResolver {
+	result := make(ChainResolver, 0, len(c))
+	for _, resolver := range c {
+		if contextResolver, ok := resolver.(BuildContextResolver); ok {
+			resolver = contextResolver.WithBuildContext(ctx)
+		}
+		result = append(result, resolver)
+	}
+
+	return result
+}
+
+// ResolverString returns the description of the resolver: the result of String method if the resolver implements
+// fmt.Stringer, the type of the resolver otherwise
+func ResolverString(resolver PackageNameResolver) string {
+	if resolver == nil {
+		return ""
+	}
+	if stringer, ok := resolver.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	return fmt.Sprintf("%T", resolver)
+}
+
+// filter returns only imports of the import paths
+func (p PackageImports) filter(importPaths []string) PackageImports {
+	result := make(PackageImports, len(importPaths))
+	for _, importPath := range importPaths {
+		if name, ok := p[importPath]; ok {
+			result[importPath] = name
+		}
+	}
+
+	return result
+}
+
+// readPackageName returns the package name from the package clause of the Go files in dir. Test files, `main` and
+// `documentation` packages are skipped, the most common name is used if files disagree.
+func readPackageName(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	fset := token.NewFileSet()
+	counts := map[string]int{}
+	var name string
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".go") || strings.HasSuffix(fileName, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, fileName), nil, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+
+		pkgName := f.Name.Name
+		if pkgName == "main" || pkgName == "documentation" {
+			continue
+		}
+
+		counts[pkgName]++
+		if counts[pkgName] > counts[name] {
+			name = pkgName
+		}
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("no package clause found in %s", dir)
+	}
+
+	return name, nil
+}
+
+// readPackageExports returns exported top-level names of the package in dir from all its Go files, regardless of
+// their build constraints. Test files, `main` and `documentation` packages are skipped.
+func readPackageExports(dir string) (map[string]struct{}, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var fileNames []string
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".go") || strings.HasSuffix(fileName, "_test.go") {
+			continue
+		}
+
+		filePath := filepath.Join(dir, fileName)
+		f, err := parser.ParseFile(fset, filePath, nil, parser.PackageClauseOnly)
+		if err != nil || f.Name.Name == "main" || f.Name.Name == "documentation" {
+			continue
+		}
+		fileNames = append(fileNames, filePath)
+	}
+
+	if len(fileNames) == 0 {
+		return nil, fmt.Errorf("no Go files found in %s", dir)
+	}
+
+	return filesExports(fset, fileNames), nil
+}
+
+// moduleRoot returns the root directory of the module which contains dir. Relative dir is resolved against the
+// working directory, so parent directories of the working directory are looked up too.
+func moduleRoot(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	return goModule.GoModRootPath(absDir)
+}
+
+func longestModulePrefix(modules map[string]string, importPath string) (string, string) {
+	var modPath, modDir string
+	for path, dir := range modules {
+		if (importPath == path || strings.HasPrefix(importPath, path+"/")) && len(path) > len(modPath) {
+			modPath, modDir = path, dir
+		}
+	}
+
+	return modPath, modDir
+}
+
+func defaultModCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		gopath = build.Default.GOPATH
+	}
+
+	return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+}
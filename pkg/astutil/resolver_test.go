@@ -0,0 +1,140 @@
+package astutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestModuleCacheResolver_ResolvePackageNames(t *testing.T) {
+	t.Parallel()
+
+	modCacheDir := t.TempDir()
+	writeTestFile(t, filepath.Join(modCacheDir, "example.com", "dep@v1.2.0", "sub", "sub.go"), "package realname\n")
+	writeTestFile(t, filepath.Join(modCacheDir, "example.com", "dep@v1.2.0", "sub", "sub_test.go"), "package realname_test\n")
+	writeTestFile(t, filepath.Join(modCacheDir, "github.com", "!burnt!sushi", "toml@v1.0.0", "toml.go"), "package toml\n")
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), `module example.com/project
+
+go 1.21
+
+require (
+	example.com/dep v1.2.0
+	example.com/replaced v1.0.0
+	github.com/BurntSushi/toml v1.0.0
+)
+
+replace example.com/replaced => ./local/replaced
+`)
+	writeTestFile(t, filepath.Join(root, "internal", "lib", "lib.go"), "package library\n")
+	writeTestFile(t, filepath.Join(root, "local", "replaced", "pkg", "pkg.go"), "package replacedpkg\n")
+
+	got, err := ModuleCacheResolver{ModCacheDir: modCacheDir}.ResolvePackageNames(root, []string{
+		"fmt",
+		"example.com/dep/sub",
+		"example.com/replaced/pkg",
+		"example.com/project/internal/lib",
+		"github.com/BurntSushi/toml",
+		"example.com/unknown",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, PackageImports{
+		"fmt":                              "fmt",
+		"example.com/dep/sub":              "realname",
+		"example.com/replaced/pkg":         "replacedpkg",
+		"example.com/project/internal/lib": "library",
+		"github.com/BurntSushi/toml":       "toml",
+	}, got)
+}
+
+func TestModuleCacheResolver_ResolvePackageNames_RelativeDir(t *testing.T) {
+	t.Parallel()
+
+	got, err := ModuleCacheResolver{}.ResolvePackageNames("testdata", []string{
+		"github.com/incu6us/goimports-reviser/v3/pkg/module",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, PackageImports{"github.com/incu6us/goimports-reviser/v3/pkg/module": "module"}, got)
+}
+
+func TestVendorResolver_ResolvePackageNames(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/project\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(root, "vendor", "example.com", "dep", "v2", "dep.go"), "package dep\n")
+	writeTestFile(t, filepath.Join(root, "cmd", "main.go"), "package main\n")
+
+	got, err := VendorResolver{}.ResolvePackageNames(filepath.Join(root, "cmd"), []string{
+		"example.com/dep/v2",
+		"example.com/unknown",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, PackageImports{"example.com/dep/v2": "dep"}, got)
+}
+
+func TestChainResolver_ResolvePackageNames(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/project\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(root, "vendor", "example.com", "go-dep", "dep.go"), "package realdep\n")
+
+	importPaths := []string{"example.com/go-dep", "example.com/go-other"}
+
+	got, err := ChainResolver{VendorResolver{}}.ResolvePackageNames(root, importPaths)
+	assert.Error(t, err)
+	assert.Equal(t, PackageImports{"example.com/go-dep": "realdep"}, got)
+
+	got, err = ChainResolver{VendorResolver{}, HeuristicResolver{}}.ResolvePackageNames(root, importPaths)
+	require.NoError(t, err)
+	assert.Equal(t, PackageImports{"example.com/go-dep": "realdep", "example.com/go-other": "other"}, got)
+}
+
+func TestChainResolver_ResolvePackageExports(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/project\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(root, "vendor", "example.com", "dep", "dep.go"), `package dep
+
+func Exported() {}
+
+func unexported() {}
+`)
+	writeTestFile(t, filepath.Join(root, "vendor", "example.com", "dep", "dep_test.go"), "package dep\n\nfunc TestOnly() {}\n")
+	writeTestFile(t, filepath.Join(root, "internal", "lib", "lib.go"), "package lib\n\ntype Lib struct{}\n")
+
+	got, err := ChainResolver{VendorResolver{}, ModuleCacheResolver{}, HeuristicResolver{}}.ResolvePackageExports(
+		root, []string{"errors", "example.com/dep", "example.com/project/internal/lib", "example.com/unknown"},
+	)
+	require.NoError(t, err)
+
+	assert.Contains(t, got["errors"], "New")
+	assert.Equal(t, map[string]struct{}{"Exported": {}}, got["example.com/dep"])
+	assert.Equal(t, map[string]struct{}{"Lib": {}}, got["example.com/project/internal/lib"])
+	assert.NotContains(t, got, "example.com/unknown")
+}
+
+func TestChainResolver_WithBuildContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := BuildContext{GOOS: "windows", GOARCH: "amd64", Tags: []string{"integration"}}
+
+	got := ChainResolver{GoListResolver{}, HeuristicResolver{}}.WithBuildContext(ctx)
+	assert.Equal(t, ChainResolver{GoListResolver{Context: ctx}, HeuristicResolver{}}, got)
+}
@@ -0,0 +1,136 @@
+package astutil
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// signatures is the cache of package signatures by package directory. An entry is valid while the state of Go files
+// of the directory(names, sizes and modification times) is the same.
+var signatures sync.Map
+
+type signatureEntry struct {
+	state     string
+	signature string
+}
+
+// LoadPackageSignatures returns signatures of the packages imported from dir whose sources can change:
+//
+//	key - package(ex.: example.com/project/log), value - signature(package name, exported names and deprecation)
+//
+// Signatures are returned for packages of the main module, of replacement directories and of vendor/ directory. Std
+// packages and packages from the module cache are omitted: they are defined by the toolchain and go.mod versions.
+// Packages which can't be found are omitted too.
+func LoadPackageSignatures(dir string, importPaths []string) (map[string]string, error) {
+	pkgDirs, err := ModuleCacheResolver{}.packageDirs(dir, importPaths)
+
+	root, _ := moduleRoot(dir)
+	modCacheDir := defaultModCacheDir() + string(filepath.Separator)
+
+	result := map[string]string{}
+	for _, importPath := range importPaths {
+		if isStdPackage(importPath) {
+			continue
+		}
+
+		pkgDir, ok := pkgDirs[importPath]
+		if root != "" {
+			vendorDir := filepath.Join(root, "vendor", filepath.FromSlash(importPath))
+			if fi, err := os.Stat(vendorDir); err == nil && fi.IsDir() {
+				pkgDir, ok = vendorDir, true
+			}
+		}
+		if !ok || strings.HasPrefix(pkgDir, modCacheDir) {
+			continue
+		}
+
+		if signature, ok := packageSignature(pkgDir); ok {
+			result[importPath] = signature
+		}
+	}
+
+	return result, err
+}
+
+// packageSignature returns the signature of the package in dir. It's read again only if Go files of dir are changed.
+func packageSignature(dir string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+
+	var (
+		fileNames []string
+		state     strings.Builder
+	)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".go") || strings.HasSuffix(fileName, "_test.go") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return "", false
+		}
+		fileNames = append(fileNames, filepath.Join(dir, fileName))
+		fmt.Fprintf(&state, "%s %d %d\n", fileName, info.Size(), info.ModTime().UnixNano())
+	}
+
+	if entry, ok := signatures.Load(dir); ok && entry.(signatureEntry).state == state.String() {
+		return entry.(signatureEntry).signature, true
+	}
+
+	signature, ok := readPackageSignature(fileNames)
+	if ok {
+		signatures.Store(dir, signatureEntry{state: state.String(), signature: signature})
+	}
+
+	return signature, ok
+}
+
+// readPackageSignature returns the package name, sorted exported names and the deprecation message of the package
+// documentation from the files. `main` and `documentation` packages are skipped.
+func readPackageSignature(fileNames []string) (string, bool) {
+	fset := token.NewFileSet()
+
+	var (
+		name        string
+		deprecation string
+		exports     = map[string]struct{}{}
+	)
+	for _, fileName := range fileNames {
+		f, err := parser.ParseFile(fset, fileName, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil || f.Name.Name == "main" || f.Name.Name == "documentation" {
+			continue
+		}
+
+		if name == "" || f.Name.Name < name {
+			name = f.Name.Name
+		}
+		if message, ok := DeprecationMessage(f.Doc); ok {
+			deprecation = message
+		}
+		for exported := range exportedNames(f) {
+			exports[exported] = struct{}{}
+		}
+	}
+
+	if name == "" {
+		return "", false
+	}
+
+	names := make([]string, 0, len(exports))
+	for exported := range exports {
+		names = append(names, exported)
+	}
+	sort.Strings(names)
+
+	return fmt.Sprintf("package %s\nexports %s\ndeprecated %s", name, strings.Join(names, ","), deprecation), true
+}
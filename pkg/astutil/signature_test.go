@@ -0,0 +1,46 @@
+package astutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPackageSignatures(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), `module example.com/project
+
+go 1.21
+
+require example.com/dep v1.2.0
+
+replace example.com/replaced => ./local/replaced
+`)
+	libFile := filepath.Join(root, "internal", "lib", "lib.go")
+	writeTestFile(t, libFile, "package library\n\nfunc Do() {}\n\nfunc do() {}\n")
+	writeTestFile(t, filepath.Join(root, "internal", "lib", "lib_test.go"), "package library\n\nfunc TestOnly() {}\n")
+	writeTestFile(t, filepath.Join(root, "vendor", "example.com", "vendored", "v.go"), "// Deprecated: use lib.\npackage vendored\n")
+
+	importPaths := []string{"fmt", "example.com/dep", "example.com/project/internal/lib", "example.com/vendored", "example.com/unknown"}
+
+	got, err := LoadPackageSignatures(root, importPaths)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"example.com/project/internal/lib": "package library\nexports Do\ndeprecated ",
+		"example.com/vendored":             "package vendored\nexports \ndeprecated use lib.",
+	}, got)
+
+	// the signature is read again after the package is changed
+	writeTestFile(t, libFile, "package library\n\nfunc Do() {}\n\nfunc Done() {}\n")
+	require.NoError(t, os.Chtimes(libFile, time.Now(), time.Now().Add(time.Second)))
+
+	got, err = LoadPackageSignatures(root, importPaths)
+	require.NoError(t, err)
+	assert.Equal(t, "package library\nexports Do,Done\ndeprecated ", got["example.com/project/internal/lib"])
+}
@@ -5,9 +5,9 @@ package testdata
 import (
 	"fmt"
 
-	"golang.org/x/exp/slices"
+	"golang.org/x/mod/semver"
 )
 
 func main() {
-	fmt.Println(slices.IsSorted([]int{1, 2, 3}))
+	fmt.Println(semver.IsValid("v1.0.0"))
 }
@@ -0,0 +1,190 @@
+package astutil
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	xastutil "golang.org/x/tools/go/ast/astutil"
+)
+
+// NewPackageImporter returns importer which creates packages from their names instead of loading them: package
+// objects are created for exported names if they are known, with an invalid type. Type-checking against these packages
+// is fast and resolves which import an identifier refers to, but not the types. Packages without a name in names fail
+// to import. Imported packages are cached, so the importer should be created per file. It is not safe for concurrent
+// use.
+func NewPackageImporter(names PackageImports, exports PackageExports) types.ImporterFrom {
+	return &packageImporter{names: names, exports: exports, packages: map[string]*types.Package{}}
+}
+
+type packageImporter struct {
+	names    PackageImports
+	exports  PackageExports
+	packages map[string]*types.Package
+}
+
+func (i *packageImporter) Import(path string) (*types.Package, error) {
+	return i.ImportFrom(path, "", 0)
+}
+
+func (i *packageImporter) ImportFrom(path, _ string, _ types.ImportMode) (*types.Package, error) {
+	if pkg, ok := i.packages[path]; ok {
+		return pkg, nil
+	}
+
+	name, ok := i.names[path]
+	if !ok || name == "" {
+		return nil, fmt.Errorf("unknown package name: %s", path)
+	}
+
+	pkg := types.NewPackage(path, name)
+	for exported := range i.exports[path] {
+		pkg.Scope().Insert(types.NewVar(token.NoPos, pkg, exported, types.Typ[types.Invalid]))
+	}
+	pkg.MarkComplete()
+	i.packages[path] = pkg
+
+	return pkg, nil
+}
+
+// TypeCheckFile type-checks the file on its own, without other files of the package. Type errors are ignored, so the
+// result is best-effort: identifiers which are declared in other files of the package are not resolved.
+func TypeCheckFile(fset *token.FileSet, f *ast.File, imp types.Importer) *types.Info {
+	info := &types.Info{
+		Defs:      map[*ast.Ident]types.Object{},
+		Uses:      map[*ast.Ident]types.Object{},
+		Implicits: map[ast.Node]types.Object{},
+	}
+
+	cfg := &types.Config{
+		Importer:    imp,
+		FakeImportC: true,
+		Error:       func(error) {},
+	}
+	_, _ = cfg.Check(f.Name.Name, fset, []*ast.File{f}, info)
+
+	return info
+}
+
+// UnusedImports returns imports of the file which are not used. The file is type-checked on its own, so uses are
+// resolved correctly for shadowed names and the rest of the package doesn't need to compile. Blank and dot imports,
+// `import "C"`, imports which failed to load and imports which are required by compiler directives(see
+// DirectiveImports) are never reported as unused.
+func UnusedImports(fset *token.FileSet, f *ast.File, imp types.Importer) map[*ast.ImportSpec]struct{} {
+	recorder := &failedImportsRecorder{importer: imp, failed: map[string]struct{}{}}
+	info := TypeCheckFile(fset, f, recorder)
+
+	usedPkgNames := map[*types.PkgName]struct{}{}
+	for _, obj := range info.Uses {
+		if pkgName, ok := obj.(*types.PkgName); ok {
+			usedPkgNames[pkgName] = struct{}{}
+		}
+	}
+
+	unused := map[*ast.ImportSpec]struct{}{}
+	for _, importSpec := range f.Imports {
+		importPath := strings.Trim(importSpec.Path.Value, `"`)
+		if importSpec.Name.String() == "_" || importSpec.Name.String() == "." || importPath == "C" {
+			continue
+		}
+		if _, ok := recorder.failed[importPath]; ok || isDirectiveImport(f, importPath) {
+			continue
+		}
+
+		var obj types.Object
+		if importSpec.Name != nil {
+			obj = info.Defs[importSpec.Name]
+		} else {
+			obj = info.Implicits[importSpec]
+		}
+		pkgName, ok := obj.(*types.PkgName)
+		if !ok {
+			continue
+		}
+
+		if _, ok := usedPkgNames[pkgName]; !ok {
+			unused[importSpec] = struct{}{}
+		}
+	}
+
+	return unused
+}
+
+// failedImportsRecorder records paths of the packages which failed to import
+type failedImportsRecorder struct {
+	importer types.Importer
+	failed   map[string]struct{}
+}
+
+func (i *failedImportsRecorder) Import(path string) (*types.Package, error) {
+	return i.ImportFrom(path, "", 0)
+}
+
+func (i *failedImportsRecorder) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	var (
+		pkg *types.Package
+		err error
+	)
+	if imp, ok := i.importer.(types.ImporterFrom); ok {
+		pkg, err = imp.ImportFrom(path, dir, mode)
+	} else {
+		pkg, err = i.importer.Import(path)
+	}
+	if err != nil {
+		i.failed[path] = struct{}{}
+	}
+
+	return pkg, err
+}
+
+// RewriteImportUses replaces selector expressions of the package with name, like `name.Func`, with the result of
+// rewrite. The selector is kept if rewrite returns nil. Identifiers which are resolved to declarations inside the
+// file(variables, params, etc.) are skipped.
+func RewriteImportUses(f *ast.File, name string, rewrite func(sel *ast.SelectorExpr) ast.Expr) {
+	xastutil.Apply(f, func(c *xastutil.Cursor) bool {
+		sel, ok := c.Node().(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		ident, ok := sel.X.(*ast.Ident)
+		if !ok || ident.Name != name || ident.Obj != nil {
+			return true
+		}
+
+		if expr := rewrite(sel); expr != nil {
+			c.Replace(expr)
+		}
+
+		return true
+	}, nil)
+}
+
+// QualifyDotImportUses qualifies identifiers which are resolved through the dot import of pkg with name.
+// Ex.: `ToLower("A")` will be replaced with `strings.ToLower("A")`
+func QualifyDotImportUses(f *ast.File, info *types.Info, pkg *types.Package, name string) {
+	xastutil.Apply(f, func(c *xastutil.Cursor) bool {
+		ident, ok := c.Node().(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		if _, ok := c.Parent().(*ast.SelectorExpr); ok && c.Name() == "Sel" {
+			return true
+		}
+
+		obj := info.Uses[ident]
+		if obj == nil || obj.Pkg() != pkg || obj.Parent() != pkg.Scope() {
+			return true
+		}
+
+		c.Replace(&ast.SelectorExpr{
+			X:   &ast.Ident{NamePos: ident.Pos(), Name: name},
+			Sel: ident,
+		})
+
+		return true
+	}, nil)
+}
@@ -0,0 +1,101 @@
+package astutil
+
+import (
+	"bytes"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQualifyDotImportUses(t *testing.T) {
+	t.Parallel()
+
+	fileData := `package main
+
+import (
+	. "strings"
+)
+
+func main() {
+	var b Builder
+	b.WriteString(ToLower("A"))
+	ToUpper := func(s string) string { return s }
+	_ = ToUpper("a")
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", []byte(fileData), parser.ParseComments)
+	require.NoError(t, err)
+
+	imp := NewPackageImporter(
+		PackageImports{"strings": "strings"},
+		PackageExports{"strings": {"Builder": {}, "ToLower": {}, "ToUpper": {}}},
+	)
+	info := TypeCheckFile(fset, f, imp)
+	pkg, err := imp.Import("strings")
+	require.NoError(t, err)
+
+	QualifyDotImportUses(f, info, pkg, "strings")
+
+	var buf bytes.Buffer
+	require.NoError(t, printer.Fprint(&buf, fset, f))
+	assert.Equal(t, `package main
+
+import (
+	. "strings"
+)
+
+func main() {
+	var b strings.Builder
+	b.WriteString(strings.ToLower("A"))
+	ToUpper := func(s string) string { return s }
+	_ = ToUpper("a")
+}
+`, buf.String())
+}
+
+func TestUnusedImports(t *testing.T) {
+	t.Parallel()
+
+	fileData := `package main
+
+import (
+	"fmt"
+	_ "net/http/pprof"
+	. "path"
+	str "strings"
+	"strconv"
+	"github.com/not/existing/pkg"
+	"os"
+)
+
+func main() {
+	fmt := func(s string) string { return s }
+	_ = fmt("a")
+	_ = str.ToLower("A")
+	_ = undefinedInThisFile(os.Args)
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", []byte(fileData), parser.ParseComments)
+	require.NoError(t, err)
+
+	imp := NewPackageImporter(PackageImports{
+		"fmt":     "fmt",
+		"path":    "path",
+		"strings": "strings",
+		"strconv": "strconv",
+		"os":      "os",
+	}, nil)
+
+	var got []string
+	for importSpec := range UnusedImports(fset, f, imp) {
+		got = append(got, importSpec.Path.Value)
+	}
+
+	assert.ElementsMatch(t, []string{`"fmt"`, `"strconv"`}, got)
+}
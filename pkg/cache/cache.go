@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	dirName = "goimports-reviser"
+	// entriesDirName is a directory of the entries inside the cache directory. Files of the previous cache format are
+	// stored in the cache directory itself, so they are removed only by Clean.
+	entriesDirName = "v1"
+
+	// DefaultMaxSize is the size of the cache in bytes after pruning
+	DefaultMaxSize int64 = 64 << 20
+)
+
+// Cache is a content-addressed storage of results. Keys are derived from everything which affects the result(see Key),
+// so entries are never invalidated: entries which are not used anymore are removed by Prune, least recently used first.
+// It's safe for concurrent use by goroutines and processes.
+type Cache struct {
+	dir     string
+	maxSize int64
+}
+
+// New creates the cache in dir, DefaultDir is used if dir is empty. Entries are pruned to maxSize bytes,
+// DefaultMaxSize is used if maxSize is not positive.
+func New(dir string, maxSize int64) (*Cache, error) {
+	if dir == "" {
+		var err error
+		dir, err = DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, entriesDirName), 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Cache{dir: dir, maxSize: maxSize}, nil
+}
+
+// DefaultDir returns goimports-reviser directory in $XDG_CACHE_HOME, or in ~/.cache if it's not set
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, dirName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".cache", dirName), nil
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Key returns the key of the parts. Parts are length-prefixed, so different splits of the same bytes have different
+// keys.
+func Key(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(part)))
+		h.Write(size[:])
+		h.Write(part)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns data of the entry. The entry is marked as recently used.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	path := c.entryPath(key)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return data, true
+}
+
+// Put stores data of the entry. The entry is written to a temporary file and renamed, so readers never see a partial
+// entry.
+func (c *Cache) Put(key string, data []byte) error {
+	path := c.entryPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Prune removes least recently used entries until the size of the cache is not greater than its max size
+func (c *Cache) Prune() error {
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var (
+		entries []entry
+		size    int64
+	)
+	err := filepath.WalkDir(filepath.Join(c.dir, entriesDirName), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+
+	for _, e := range entries {
+		if size <= c.maxSize {
+			break
+		}
+		if err := os.Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		size -= e.size
+	}
+
+	return nil
+}
+
+// Clean removes all entries of the cache, including entries of the previous cache format(files named by MD5 hash of
+// the file path). Other files of the cache directory are kept.
+func (c *Cache) Clean() error {
+	if err := os.RemoveAll(filepath.Join(c.dir, entriesDirName)); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && isLegacyEntry(entry.Name()) {
+			if err := os.Remove(filepath.Join(c.dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return os.MkdirAll(filepath.Join(c.dir, entriesDirName), 0o755)
+}
+
+func isLegacyEntry(name string) bool {
+	_, err := hex.DecodeString(name)
+	return err == nil && len(name) == hex.EncodedLen(md5.Size)
+}
+
+func (c *Cache) entryPath(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(c.dir, entriesDirName, key)
+	}
+
+	return filepath.Join(c.dir, entriesDirName, key[:2], key)
+}
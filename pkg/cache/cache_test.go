@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Key([]byte("a"), []byte("b")), Key([]byte("a"), []byte("b")))
+	assert.NotEqual(t, Key([]byte("ab"), []byte("")), Key([]byte("a"), []byte("b")))
+	assert.Len(t, Key(), 64)
+}
+
+func TestCache_GetPut(t *testing.T) {
+	t.Parallel()
+
+	c, err := New(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	key := Key([]byte("content"))
+	_, ok := c.Get(key)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Put(key, []byte("result")))
+	got, ok := c.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("result"), got)
+
+	require.NoError(t, c.Put(key, []byte("new result")))
+	got, ok = c.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("new result"), got)
+}
+
+func TestCache_Prune(t *testing.T) {
+	t.Parallel()
+
+	c, err := New(t.TempDir(), 10)
+	require.NoError(t, err)
+
+	keys := []string{Key([]byte("1")), Key([]byte("2")), Key([]byte("3"))}
+	for i, key := range keys {
+		require.NoError(t, c.Put(key, []byte("12345")))
+		modTime := time.Now().Add(time.Duration(i-10) * time.Minute)
+		require.NoError(t, os.Chtimes(c.entryPath(key), modTime, modTime))
+	}
+
+	// the oldest entry becomes the most recently used
+	_, ok := c.Get(keys[0])
+	require.True(t, ok)
+
+	require.NoError(t, c.Prune())
+
+	_, ok = c.Get(keys[0])
+	assert.True(t, ok)
+	_, ok = c.Get(keys[1])
+	assert.False(t, ok)
+	_, ok = c.Get(keys[2])
+	assert.True(t, ok)
+}
+
+func TestCache_Clean(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	legacyEntry := filepath.Join(dir, "0cc175b9c0f1b6a831c399e269772661")
+	otherFile := filepath.Join(dir, "other.txt")
+	require.NoError(t, os.WriteFile(legacyEntry, []byte("hash"), 0o644))
+	require.NoError(t, os.WriteFile(otherFile, []byte("other"), 0o644))
+
+	c, err := New(dir, 0)
+	require.NoError(t, err)
+
+	key := Key([]byte("content"))
+	require.NoError(t, c.Put(key, []byte("result")))
+
+	require.NoError(t, c.Clean())
+
+	_, ok := c.Get(key)
+	assert.False(t, ok)
+	assert.NoFileExists(t, legacyEntry)
+	assert.FileExists(t, otherFile)
+
+	require.NoError(t, c.Put(key, []byte("result")))
+}
+
+func TestDefaultDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg")
+
+	dir, err := DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/tmp/xdg", "goimports-reviser"), dir)
+}
@@ -0,0 +1,186 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// changedFilter keeps added, copied, modified and renamed files: deleted files can't be fixed
+const changedFilter = "--diff-filter=ACMR"
+
+// Repository is a git work tree
+type Repository struct {
+	root string
+}
+
+// Open returns the repository which contains dir
+func Open(dir string) (*Repository, error) {
+	out, err := run(dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.Abs(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	return &Repository{root: root}, nil
+}
+
+// Root returns the root directory of the work tree
+func (r *Repository) Root() string {
+	return r.root
+}
+
+// ChangedFiles returns absolute paths of the files which were changed since ref: committed after ref, staged and
+// unstaged changes, and untracked files which are not ignored. Results are limited to paths(files or directories) if
+// they are set.
+func (r *Repository) ChangedFiles(ref string, paths ...string) ([]string, error) {
+	pathspecs, err := r.pathspecs(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := r.files(append([]string{"diff", "--name-only", "-z", changedFilter, ref, "--"}, pathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := r.files(append([]string{"ls-files", "-z", "--others", "--exclude-standard", "--"}, pathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(changed, untracked...), nil
+}
+
+// StagedFiles returns absolute paths of the files which have staged changes. Results are limited to paths(files or
+// directories) if they are set.
+func (r *Repository) StagedFiles(paths ...string) ([]string, error) {
+	pathspecs, err := r.pathspecs(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.files(append([]string{"diff", "--cached", "--name-only", "-z", changedFilter, "--"}, pathspecs...)...)
+}
+
+// ReadStaged returns the content of the file from the index
+func (r *Repository) ReadStaged(path string) ([]byte, error) {
+	name, err := r.name(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return run(r.root, nil, "cat-file", "blob", ":"+name)
+}
+
+// WriteStaged replaces the content of the file in the index. The file mode is kept, the work tree is not changed.
+func (r *Repository) WriteStaged(path string, content []byte) error {
+	name, err := r.name(path)
+	if err != nil {
+		return err
+	}
+
+	out, err := run(r.root, nil, "ls-files", "--stage", "-z", "--", name)
+	if err != nil {
+		return err
+	}
+	// <mode> <object> <stage>\t<file>
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return fmt.Errorf("file %s is not in the index", name)
+	}
+	mode := fields[0]
+
+	object, err := run(r.root, content, "hash-object", "-w", "--stdin", "--path", name)
+	if err != nil {
+		return err
+	}
+
+	_, err = run(
+		r.root, nil, "update-index", "--cacheinfo", mode+","+strings.TrimSpace(string(object))+","+name,
+	)
+
+	return err
+}
+
+// files runs the command which prints NUL-separated file names relative to the root and returns absolute paths
+func (r *Repository) files(args ...string) ([]string, error) {
+	out, err := run(r.root, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			files = append(files, filepath.Join(r.root, filepath.FromSlash(name)))
+		}
+	}
+
+	return files, nil
+}
+
+// pathspecs converts paths to literal pathspecs relative to the root
+func (r *Repository) pathspecs(paths []string) ([]string, error) {
+	pathspecs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		name, err := r.name(path)
+		if err != nil {
+			return nil, err
+		}
+		pathspecs = append(pathspecs, ":(literal)"+name)
+	}
+
+	return pathspecs, nil
+}
+
+// name returns the path of the file relative to the root in the format of the index
+func (r *Repository) name(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// the root is resolved by git, so symlinks of the path(ex.: /tmp on macOS) are resolved too
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
+
+	rel, err := filepath.Rel(r.root, absPath)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file %s is outside of the repository %s", path, r.root)
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+func run(dir string, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out, nil
+}
@@ -0,0 +1,180 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepository creates a repository with the committed files and returns its root
+func newTestRepository(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q")
+	gitRun(t, dir, "config", "user.email", "test@example.com")
+	gitRun(t, dir, "config", "user.name", "test")
+	gitRun(t, dir, "config", "commit.gpgsign", "false")
+
+	for name, content := range files {
+		writeFile(t, dir, name, content)
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-q", "-m", "init")
+
+	return dir
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	out, err := run(dir, nil, args...)
+	require.NoError(t, err)
+
+	return string(out)
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	dir := newTestRepository(t, map[string]string{"a/a.go": "package a\n"})
+
+	repo, err := Open(filepath.Join(dir, "a"))
+	require.NoError(t, err)
+
+	root, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	assert.Equal(t, root, repo.Root())
+
+	_, err = Open(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestRepository_ChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := newTestRepository(t, map[string]string{
+		"a.go":       "package a\n",
+		"b.go":       "package a\n",
+		"c.go":       "package a\n",
+		"sub/d.go":   "package sub\n",
+		"deleted.go": "package a\n",
+		".gitignore": "ignored.go\n",
+	})
+	gitRun(t, dir, "tag", "base")
+
+	writeFile(t, dir, "a.go", "package a\n\n// committed\n")
+	gitRun(t, dir, "commit", "-q", "-am", "change")
+	writeFile(t, dir, "b.go", "package a\n\n// staged\n")
+	gitRun(t, dir, "add", "b.go")
+	writeFile(t, dir, "sub/d.go", "package sub\n\n// unstaged\n")
+	writeFile(t, dir, "sub/untracked.go", "package sub\n")
+	writeFile(t, dir, "ignored.go", "package a\n")
+	require.NoError(t, os.Remove(filepath.Join(dir, "deleted.go")))
+
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	root := repo.Root()
+
+	files, err := repo.ChangedFiles("base")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(root, "a.go"),
+		filepath.Join(root, "b.go"),
+		filepath.Join(root, "sub", "d.go"),
+		filepath.Join(root, "sub", "untracked.go"),
+	}, files)
+
+	files, err = repo.ChangedFiles("HEAD", filepath.Join(dir, "sub"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(root, "sub", "d.go"),
+		filepath.Join(root, "sub", "untracked.go"),
+	}, files)
+
+	_, err = repo.ChangedFiles("base", t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestRepository_StagedFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := newTestRepository(t, map[string]string{
+		"a.go":     "package a\n",
+		"b.go":     "package a\n",
+		"sub/c.go": "package sub\n",
+	})
+
+	writeFile(t, dir, "a.go", "package a\n\n// staged\n")
+	writeFile(t, dir, "sub/c.go", "package sub\n\n// staged\n")
+	writeFile(t, dir, "sub/new.go", "package sub\n")
+	gitRun(t, dir, "add", "a.go", "sub")
+	writeFile(t, dir, "b.go", "package a\n\n// unstaged\n")
+
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	root := repo.Root()
+
+	files, err := repo.StagedFiles()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(root, "a.go"),
+		filepath.Join(root, "sub", "c.go"),
+		filepath.Join(root, "sub", "new.go"),
+	}, files)
+
+	files, err = repo.StagedFiles(filepath.Join(dir, "sub", "c.go"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "sub", "c.go")}, files)
+}
+
+func TestRepository_ReadWriteStaged(t *testing.T) {
+	t.Parallel()
+
+	dir := newTestRepository(t, map[string]string{"a.go": "package a\n"})
+
+	writeFile(t, dir, "a.go", "package a\n\n// staged\n")
+	gitRun(t, dir, "add", "a.go")
+	gitRun(t, dir, "update-index", "--chmod=+x", "a.go")
+	writeFile(t, dir, "a.go", "package a\n\n// unstaged\n")
+
+	repo, err := Open(dir)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "a.go")
+	content, err := repo.ReadStaged(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n\n// staged\n", string(content))
+
+	require.NoError(t, repo.WriteStaged(path, []byte("package a\n\n// fixed\n")))
+
+	content, err = repo.ReadStaged(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n\n// fixed\n", string(content))
+	assert.Regexp(t, `^100755 `, gitRun(t, dir, "ls-files", "--stage", "a.go"))
+
+	workTreeContent, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n\n// unstaged\n", string(workTreeContent))
+
+	_, err = repo.ReadStaged(filepath.Join(dir, "missing.go"))
+	assert.Error(t, err)
+	assert.Error(t, repo.WriteStaged(filepath.Join(dir, "missing.go"), []byte("package a\n")))
+	assert.Error(t, repo.WriteStaged(filepath.Join(t.TempDir(), "a.go"), []byte("package a\n")))
+}
@@ -48,7 +48,8 @@ func run(localPkgPrefixes string, options ...reviser.SourceFileOption) func(pass
 						continue
 					}
 
-					if origDd.Tok != token.IMPORT {
+					// declarations with `//goimports-reviser:keep-order` directive are kept as is
+					if origDd.Tok != token.IMPORT || reviser.HasKeepOrderDirective(origDd) {
 						continue
 					}
 
@@ -66,6 +67,9 @@ func run(localPkgPrefixes string, options ...reviser.SourceFileOption) func(pass
 
 		var projectName string
 
+		// files of the pass belong to the same package, so its imports are loaded once
+		options := append([]reviser.SourceFileOption{reviser.WithPackageImportsCache(reviser.NewPackageImportsCache())}, options...)
+
 		for _, f := range pass.Files {
 			filePath := pass.Fset.File(f.Package).Name()
 
@@ -77,11 +81,14 @@ func run(localPkgPrefixes string, options ...reviser.SourceFileOption) func(pass
 				}
 			}
 
-			formattedFileContent, _, hasChanged, err := reviser.NewSourceFile(projectName, filePath).Fix(options...)
+			sourceFile := reviser.NewSourceFile(projectName, filePath)
+			formattedFileContent, _, hasChanged, err := sourceFile.Fix(options...)
 			if err != nil {
 				return nil, err
 			}
 
+			reportDiagnostics(pass, f, sourceFile.Diagnostics())
+
 			if !hasChanged {
 				continue
 			}
@@ -97,3 +104,19 @@ func run(localPkgPrefixes string, options ...reviser.SourceFileOption) func(pass
 		return nil, nil
 	}
 }
+
+// reportDiagnostics reports diagnostics of the reviser on positions of the analyzed file
+func reportDiagnostics(pass *analysis.Pass, file *ast.File, diagnostics []reviser.Diagnostic) {
+	tokenFile := pass.Fset.File(file.Package)
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Pos.Line < 1 || diagnostic.Pos.Line > tokenFile.LineCount() {
+			continue
+		}
+
+		pass.Report(analysis.Diagnostic{
+			Pos:      tokenFile.LineStart(diagnostic.Pos.Line) + token.Pos(diagnostic.Pos.Column-1),
+			Category: diagnostic.Category,
+			Message:  diagnostic.Message,
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package module
+
+import (
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// ImportModule is the module of the import path regardless of its major version
+type ImportModule struct {
+	// PathPrefix is the module path without the major version suffix(ex.: github.com/foo/bar)
+	PathPrefix string
+	// PathMajor is the major version suffix of the import path(ex.: /v3, .v3 for gopkg.in or empty for v0 and v1)
+	PathMajor string
+	// Subpath is the package path inside the module(ex.: /pkg/sub or empty)
+	Subpath string
+}
+
+// MajorVersions groups modulePaths by the module path without the major version suffix:
+//
+//	key - path prefix(ex.: github.com/foo/bar), value - major version suffixes(ex.: "", /v3)
+func MajorVersions(modulePaths []string) map[string][]string {
+	result := map[string][]string{}
+	for _, modulePath := range modulePaths {
+		prefix, pathMajor, ok := module.SplitPathVersion(modulePath)
+		if !ok {
+			continue
+		}
+		result[prefix] = append(result[prefix], pathMajor)
+	}
+
+	return result
+}
+
+// SplitImportPath finds the module of the import path among majorVersions(see MajorVersions) ignoring major
+// versions: "github.com/foo/bar/pkg" and "github.com/foo/bar/v3/pkg" both belong to "github.com/foo/bar". The longest
+// module path wins. Returns false if the import path doesn't belong to any of the modules.
+func SplitImportPath(majorVersions map[string][]string, importPath string) (ImportModule, bool) {
+	var (
+		result ImportModule
+		found  bool
+	)
+
+	for end := len(importPath); end > 0; end = strings.LastIndex(importPath[:end], "/") {
+		prefix, pathMajor, ok := module.SplitPathVersion(importPath[:end])
+		if !ok {
+			continue
+		}
+
+		if _, ok := majorVersions[prefix]; ok {
+			result = ImportModule{PathPrefix: prefix, PathMajor: pathMajor, Subpath: importPath[end:]}
+			found = true
+			break
+		}
+	}
+
+	return result, found
+}
@@ -0,0 +1,77 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitImportPath(t *testing.T) {
+	t.Parallel()
+
+	majorVersions := MajorVersions([]string{
+		"github.com/foo/bar/v3",
+		"github.com/foo/bar/sub",
+		"github.com/baz/qux",
+		"gopkg.in/yaml.v3",
+	})
+	assert.Equal(t, map[string][]string{
+		"github.com/foo/bar":     {"/v3"},
+		"github.com/foo/bar/sub": {""},
+		"github.com/baz/qux":     {""},
+		"gopkg.in/yaml":          {".v3"},
+	}, majorVersions)
+
+	tests := []struct {
+		name       string
+		importPath string
+		want       ImportModule
+		wantOk     bool
+	}{
+		{
+			name:       "required major version",
+			importPath: "github.com/foo/bar/v3/pkg",
+			want:       ImportModule{PathPrefix: "github.com/foo/bar", PathMajor: "/v3", Subpath: "/pkg"},
+			wantOk:     true,
+		},
+		{
+			name:       "without major version",
+			importPath: "github.com/foo/bar/pkg",
+			want:       ImportModule{PathPrefix: "github.com/foo/bar", Subpath: "/pkg"},
+			wantOk:     true,
+		},
+		{
+			name:       "other major version",
+			importPath: "github.com/baz/qux/v2",
+			want:       ImportModule{PathPrefix: "github.com/baz/qux", PathMajor: "/v2"},
+			wantOk:     true,
+		},
+		{
+			name:       "nested module",
+			importPath: "github.com/foo/bar/sub/pkg",
+			want:       ImportModule{PathPrefix: "github.com/foo/bar/sub", Subpath: "/pkg"},
+			wantOk:     true,
+		},
+		{
+			name:       "gopkg.in",
+			importPath: "gopkg.in/yaml.v2",
+			want:       ImportModule{PathPrefix: "gopkg.in/yaml", PathMajor: ".v2"},
+			wantOk:     true,
+		},
+		{
+			name:       "unknown module",
+			importPath: "github.com/unknown/pkg",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := SplitImportPath(majorVersions, tt.importPath)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -11,23 +11,73 @@ const goModFilename = "go.mod"
 
 // Name reads module value from ./go.mod
 func Name(goModRootPath string) (string, error) {
-	goModFile := filepath.Join(goModRootPath, goModFilename)
+	f, err := parseGoMod(goModRootPath)
+	if err != nil {
+		return "", err
+	}
 
-	data, err := os.ReadFile(goModFile)
+	if f.Module != nil {
+		return f.Module.Mod.Path, nil
+	}
+
+	return "", &UndefinedModuleError{}
+}
+
+// GoVersion reads go directive value(ex.: 1.21) from ./go.mod. Returns empty string if the directive is absent.
+func GoVersion(goModRootPath string) (string, error) {
+	f, err := parseGoMod(goModRootPath)
 	if err != nil {
 		return "", err
 	}
 
-	f, err := modfile.Parse(goModFile, data, nil)
+	if f.Go != nil {
+		return f.Go.Version, nil
+	}
+
+	return "", nil
+}
+
+// RequiredVersion reads the version of the required module from ./go.mod. Returns empty string if the module is not
+// required.
+func RequiredVersion(goModRootPath, modulePath string) (string, error) {
+	f, err := parseGoMod(goModRootPath)
 	if err != nil {
 		return "", err
 	}
 
-	if f.Module != nil {
-		return f.Module.Mod.Path, nil
+	for _, require := range f.Require {
+		if require.Mod.Path == modulePath {
+			return require.Mod.Version, nil
+		}
 	}
 
-	return "", &UndefinedModuleError{}
+	return "", nil
+}
+
+// RequiredModules reads paths of the modules required by ./go.mod
+func RequiredModules(goModRootPath string) ([]string, error) {
+	f, err := parseGoMod(goModRootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	modulePaths := make([]string, 0, len(f.Require))
+	for _, require := range f.Require {
+		modulePaths = append(modulePaths, require.Mod.Path)
+	}
+
+	return modulePaths, nil
+}
+
+func parseGoMod(goModRootPath string) (*modfile.File, error) {
+	goModFile := filepath.Join(goModRootPath, goModFilename)
+
+	data, err := os.ReadFile(goModFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return modfile.Parse(goModFile, data, nil)
 }
 
 // GoModRootPath in case of any directory or file of the project will return root dir of the project where go.mod file
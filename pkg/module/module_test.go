@@ -134,3 +134,30 @@ func TestDetermineProjectName(t *testing.T) {
 		})
 	}
 }
+
+func TestGoVersionAndRequiredVersion(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example.com/project
+
+go 1.21.5
+
+require golang.org/x/exp v0.0.0-20230801115018-d63ba01acd4b
+`), 0o644))
+
+	goVersion, err := GoVersion(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "1.21.5", goVersion)
+
+	version, err := RequiredVersion(dir, "golang.org/x/exp")
+	require.NoError(t, err)
+	assert.Equal(t, "v0.0.0-20230801115018-d63ba01acd4b", version)
+
+	version, err = RequiredVersion(dir, "golang.org/x/tools")
+	require.NoError(t, err)
+	assert.Empty(t, version)
+
+	_, err = GoVersion(t.TempDir())
+	assert.Error(t, err)
+}
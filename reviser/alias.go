@@ -0,0 +1,546 @@
+package reviser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+	"github.com/incu6us/goimports-reviser/v3/pkg/std"
+)
+
+const (
+	aliasValueSeparator = "="
+
+	nameCollisionCategory = "name-collision"
+
+	defaultCollisionPriority = "std,project,company,general"
+
+	aliasTemplateName    = "{{name}}"
+	aliasTemplateParent  = "{{parent}}"
+	aliasTemplateVersion = "{{version}}"
+)
+
+var (
+	versionedNamePattern     = regexp.MustCompile(`^v[0-9]+((alpha|beta)[0-9]+)?$`)
+	aliasPlaceholderPattern  = regexp.MustCompile(`{{[^{}]*}}`)
+	aliasTemplatePlaceholder = map[string]struct{}{
+		aliasTemplateName:    {},
+		aliasTemplateParent:  {},
+		aliasTemplateVersion: {},
+	}
+)
+
+// StringToCanonicalAliases will convert string, like "k8s.io/api/core/v1=corev1,k8s.io/api/apps/v1=appsv1" to
+// a map of import paths with their required aliases
+func StringToCanonicalAliases(s string) (map[string]string, error) {
+	aliases := map[string]string{}
+	for _, pair := range strings.Split(s, stringValueSeparator) {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		values := strings.Split(pair, aliasValueSeparator)
+		if len(values) != 2 {
+			return nil, fmt.Errorf(`invalid canonical alias %q, expected format is "path=alias"`, pair)
+		}
+
+		importPath, alias := strings.TrimSpace(values[0]), strings.TrimSpace(values[1])
+		if importPath == "" || !token.IsIdentifier(alias) || alias == "_" {
+			return nil, fmt.Errorf(`invalid canonical alias %q, expected format is "path=alias"`, pair)
+		}
+
+		if existing, ok := aliases[importPath]; ok && existing != alias {
+			return nil, fmt.Errorf(`conflicting canonical aliases for %q: %q and %q`, importPath, existing, alias)
+		}
+
+		aliases[importPath] = alias
+	}
+
+	return aliases, nil
+}
+
+// applyCanonicalAliases sets the required alias for every matching import spec and renames all uses of the
+// previous package identifier inside the file
+func (f *SourceFile) applyCanonicalAliases(fset *token.FileSet, file *ast.File) error {
+	if len(f.canonicalAliases) == 0 {
+		return nil
+	}
+
+	for _, importSpec := range file.Imports {
+		imprt := strings.Trim(importSpec.Path.Value, `"`)
+		alias, ok := f.canonicalAliases[imprt]
+		if !ok {
+			continue
+		}
+
+		var oldName string
+		if importSpec.Name != nil {
+			oldName = importSpec.Name.Name
+		} else {
+			packageImports, err := f.loadPackageImports(fset, file)
+			if err != nil {
+				return err
+			}
+
+			oldName = packageName(imprt, packageImports)
+		}
+
+		switch oldName {
+		case "_", ".":
+			continue
+		case alias:
+			// the package name of an unnamed import is already the alias, so an explicit alias would be redundant
+			continue
+		}
+
+		if hasImportName(file, importSpec, alias) || astutil.IsNameDeclared(file, alias) {
+			return fmt.Errorf("failed to set canonical alias %q for %q: name is already in use in the file", alias, imprt)
+		}
+
+		importSpec.Name = &ast.Ident{NamePos: importSpec.Pos(), Name: alias}
+		astutil.RenameImportUses(file, oldName, alias)
+	}
+
+	return nil
+}
+
+// hasImportName checks if any other import spec of the file is explicitly named as name
+func hasImportName(file *ast.File, exclude *ast.ImportSpec, name string) bool {
+	for _, importSpec := range file.Imports {
+		if importSpec != exclude && importSpec.Name != nil && importSpec.Name.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateAliasTemplate checks that template has at least one placeholder and all of them are known
+func validateAliasTemplate(tpl string) error {
+	placeholders := aliasPlaceholderPattern.FindAllString(tpl, -1)
+	if len(placeholders) == 0 {
+		return fmt.Errorf(`alias template %q has no placeholders, use %s, %s or %s`, tpl, aliasTemplateName, aliasTemplateParent, aliasTemplateVersion)
+	}
+
+	for _, placeholder := range placeholders {
+		if _, ok := aliasTemplatePlaceholder[placeholder]; !ok {
+			return fmt.Errorf(`unknown placeholder %q in alias template %q`, placeholder, tpl)
+		}
+	}
+
+	return nil
+}
+
+// renderAliasTemplate will render alias for the import path. Ex.: "k8s.io/api/apps/v1" with template
+// "{{parent}}{{version}}" will be rendered as "appsv1"
+func renderAliasTemplate(tpl, importPath, name string) string {
+	elements := strings.Split(importPath, "/")
+
+	var version string
+	pkgIdx := len(elements) - 1
+	if versionedNamePattern.MatchString(elements[pkgIdx]) {
+		version = elements[pkgIdx]
+		if name != version && pkgIdx > 0 {
+			pkgIdx--
+		}
+	}
+
+	var parent string
+	if pkgIdx > 0 {
+		parent = elements[pkgIdx-1]
+	}
+
+	return strings.NewReplacer(
+		aliasTemplateName, sanitizeIdentifier(name),
+		aliasTemplateParent, sanitizeIdentifier(parent),
+		aliasTemplateVersion, sanitizeIdentifier(version),
+	).Replace(tpl)
+}
+
+func sanitizeIdentifier(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// templatedAlias returns alias rendered by the alias template if the package name of the import looks like a version
+// (ex.: "v1") or collides with the name of another import. If the name was unambiguous, all uses of the package are
+// renamed. On a collision, only uses which are exported by the package alone are renamed; if a use can refer to
+// several colliding packages, it's reported and the alias isn't set.
+func (f *SourceFile) templatedAlias(
+	fset *token.FileSet,
+	file *ast.File,
+	importSpec *ast.ImportSpec,
+	packageImports astutil.PackageImports,
+	importNames map[string]int,
+) (string, bool, error) {
+	if f.aliasTemplate == "" {
+		return "", false, nil
+	}
+
+	imprt := strings.Trim(importSpec.Path.Value, `"`)
+	name, ok := resolvedPackageName(file, imprt, packageImports)
+	if !ok {
+		return "", false, nil
+	}
+
+	isVersionedName := versionedNamePattern.MatchString(name)
+	if !isVersionedName {
+		if importNames[name] < 2 {
+			return "", false, nil
+		}
+		// std packages keep their names on collision
+		if _, ok := std.StdPackages[imprt]; ok {
+			return "", false, nil
+		}
+	}
+
+	alias := renderAliasTemplate(f.aliasTemplate, imprt, name)
+	if !token.IsIdentifier(alias) || alias == name || importNames[alias] > 0 || astutil.IsNameDeclared(file, alias) {
+		return "", false, nil
+	}
+
+	if importNames[name] == 1 {
+		astutil.RenameImportUses(file, name, alias)
+		importNames[alias]++
+
+		return alias, true, nil
+	}
+
+	importPaths := importPathsByName(file, name, packageImports)
+	exports, err := f.loadPackageExports(fset, file, importPaths)
+	if err != nil {
+		return "", false, err
+	}
+
+	var isAmbiguous bool
+	for _, sel := range astutil.ImportUses(file, name) {
+		candidates := selectorCandidates(exports, importPaths, sel.Sel.Name)
+		if len(candidates) > 1 && slices.Contains(candidates, imprt) {
+			f.report(
+				fset, sel.Pos(), nameCollisionCategory, "%s.%s is ambiguous, it can refer to %s; alias %q isn't set for %q",
+				name, sel.Sel.Name, quoteAll(candidates), alias, imprt,
+			)
+			isAmbiguous = true
+		}
+	}
+	if isAmbiguous {
+		return "", false, nil
+	}
+
+	astutil.RenameImportUsesFunc(file, name, alias, func(selector string) bool {
+		return isExportedOnlyBy(exports, importPaths, imprt, selector)
+	})
+	importNames[alias]++
+
+	return alias, true, nil
+}
+
+// importPathsByName returns paths of the imports which effective name is name
+func importPathsByName(file *ast.File, name string, packageImports astutil.PackageImports) []string {
+	var importPaths []string
+	for _, importSpec := range file.Imports {
+		imprt := strings.Trim(importSpec.Path.Value, `"`)
+		if (importSpec.Name != nil && importSpec.Name.Name == name) ||
+			(importSpec.Name == nil && packageName(imprt, packageImports) == name) {
+			importPaths = append(importPaths, imprt)
+		}
+	}
+
+	return importPaths
+}
+
+// countImportNames returns effective names of all imports in the file with the amount of imports per name
+func countImportNames(file *ast.File, packageImports astutil.PackageImports) map[string]int {
+	names := make(map[string]int, len(file.Imports))
+	for _, importSpec := range file.Imports {
+		if importSpec.Name != nil {
+			if importSpec.Name.Name != "_" && importSpec.Name.Name != "." {
+				names[importSpec.Name.Name]++
+			}
+			continue
+		}
+		names[packageName(strings.Trim(importSpec.Path.Value, `"`), packageImports)]++
+	}
+
+	return names
+}
+
+// packageName returns the package name of the import, or the name which is assumed from the import path if the package
+// wasn't loaded
+func packageName(importPath string, packageImports astutil.PackageImports) string {
+	if name := packageImports[importPath]; name != "" {
+		return name
+	}
+
+	return astutil.GuessPackageName(importPath)
+}
+
+// resolvedPackageName returns the package name of the import if it can be trusted to set an alias. The name which was
+// resolved is trusted unless the file uses the import by the last element of the path(ex.: `v2.X`). The name which is
+// guessed from the import path is trusted only if the file uses the import by this name.
+func resolvedPackageName(file *ast.File, importPath string, packageImports astutil.PackageImports) (string, bool) {
+	name := packageImports[importPath]
+	if name == "" {
+		name = astutil.GuessPackageName(importPath)
+		return name, len(astutil.ImportUses(file, name)) > 0
+	}
+
+	base := path.Base(importPath)
+	if base != name && len(astutil.ImportUses(file, base)) > 0 && len(astutil.ImportUses(file, name)) == 0 {
+		return "", false
+	}
+
+	return name, true
+}
+
+// isRedundantAlias checks if the alias of the import only restates the real package name. The alias is kept if another
+// import of the file has the same package name(the alias resolves the collision), if it is enforced by canonical
+// aliases or if it would be set back by WithUsingAliasForVersionSuffix.
+func (f *SourceFile) isRedundantAlias(
+	importSpec *ast.ImportSpec,
+	packageImports astutil.PackageImports,
+	packageNames map[string]int,
+) bool {
+	imprt := strings.Trim(importSpec.Path.Value, `"`)
+	name, ok := packageImports[imprt]
+	if !ok || importSpec.Name == nil || importSpec.Name.Name != name {
+		return false
+	}
+
+	if packageNames[name] > 1 {
+		return false
+	}
+
+	if _, ok := f.canonicalAliases[imprt]; ok {
+		return false
+	}
+
+	if f.shouldUseAliasForVersionSuffix && path.Base(imprt) != name {
+		return false
+	}
+
+	return true
+}
+
+// countPackageNames returns real package names of all imports in the file with the amount of imports per name
+func countPackageNames(file *ast.File, packageImports astutil.PackageImports) map[string]int {
+	names := make(map[string]int, len(file.Imports))
+	for _, importSpec := range file.Imports {
+		if name, ok := packageImports[strings.Trim(importSpec.Path.Value, `"`)]; ok {
+			names[name]++
+		}
+	}
+
+	return names
+}
+
+// StringToCollisionPriority will convert string, like "std,project,company,general" to the priority of groups which
+// keep their package names on collision. Omitted groups get the lowest priority in the default order.
+// Default value for empty string is "std,project,company,general"
+func StringToCollisionPriority(s string) (ImportsOrders, error) {
+	var priority ImportsOrders
+	seen := map[ImportsOrder]struct{}{}
+	for _, g := range append(strings.Split(s, stringValueSeparator), strings.Split(defaultCollisionPriority, stringValueSeparator)...) {
+		group := ImportsOrder(strings.TrimSpace(g))
+		if group == "" {
+			continue
+		}
+
+		switch group {
+		case StdImportsOrder, ProjectImportsOrder, CompanyImportsOrder, GeneralImportsOrder:
+		default:
+			return nil, fmt.Errorf(`unknown collision priority group type: %q`, group)
+		}
+
+		if _, ok := seen[group]; ok {
+			continue
+		}
+		seen[group] = struct{}{}
+		priority = append(priority, group)
+	}
+
+	return priority, nil
+}
+
+// resolveNameCollisions finds imports with the same effective name. The import with the highest group priority keeps
+// the name(the shortest path wins inside the same group), others get an alias which is prefixed with the parent path
+// element, like "customlog". Uses of the name are rewritten only if the selector is exported by the renamed package
+// alone.
+func (f *SourceFile) resolveNameCollisions(fset *token.FileSet, file *ast.File) error {
+	if !f.shouldResolveNameCollisions {
+		return nil
+	}
+
+	packageImports, err := f.loadPackageImports(fset, file)
+	if err != nil {
+		return err
+	}
+
+	importsByName := map[string][]*ast.ImportSpec{}
+	for _, importSpec := range file.Imports {
+		imprt := strings.Trim(importSpec.Path.Value, `"`)
+		name := packageName(imprt, packageImports)
+		if importSpec.Name != nil {
+			name = importSpec.Name.Name
+		}
+		if name == "_" || name == "." || imprt == "C" {
+			continue
+		}
+		importsByName[name] = append(importsByName[name], importSpec)
+	}
+
+	names := make([]string, 0, len(importsByName))
+	for name, specs := range importsByName {
+		if len(specs) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	importNames := countImportNames(file, packageImports)
+	for _, name := range names {
+		specs := importsByName[name]
+		sort.SliceStable(specs, func(i, j int) bool {
+			iPath, jPath := strings.Trim(specs[i].Path.Value, `"`), strings.Trim(specs[j].Path.Value, `"`)
+			iPriority, jPriority := f.collisionPriority(iPath), f.collisionPriority(jPath)
+			if iPriority != jPriority {
+				return iPriority < jPriority
+			}
+			if len(iPath) != len(jPath) {
+				return len(iPath) < len(jPath)
+			}
+			return iPath < jPath
+		})
+
+		importPaths := make([]string, 0, len(specs))
+		for _, importSpec := range specs {
+			importPaths = append(importPaths, strings.Trim(importSpec.Path.Value, `"`))
+		}
+
+		exports, err := f.loadPackageExports(fset, file, importPaths)
+		if err != nil {
+			return err
+		}
+
+		for i, importSpec := range specs[1:] {
+			imprt := importPaths[i+1]
+			alias := collisionAlias(file, imprt, name, importNames)
+
+			astutil.RenameImportUsesFunc(file, name, alias, func(selector string) bool {
+				return isExportedOnlyBy(exports, importPaths, imprt, selector)
+			})
+
+			importSpec.Name = &ast.Ident{NamePos: importSpec.Pos(), Name: alias}
+			importNames[alias]++
+		}
+
+		f.reportAmbiguousSelectors(fset, file, name, exports, importPaths)
+	}
+
+	return nil
+}
+
+// reportAmbiguousSelectors reports uses of the name which were kept for the import with the highest priority, but can
+// refer to another colliding import too: the selector is exported by several packages or exports of the package are
+// unknown.
+func (f *SourceFile) reportAmbiguousSelectors(
+	fset *token.FileSet,
+	file *ast.File,
+	name string,
+	exports astutil.PackageExports,
+	importPaths []string,
+) {
+	for _, sel := range astutil.ImportUses(file, name) {
+		candidates := selectorCandidates(exports, importPaths, sel.Sel.Name)
+		if len(candidates) < 2 {
+			continue
+		}
+
+		f.report(
+			fset, sel.Pos(), nameCollisionCategory, "%s.%s is ambiguous, it can refer to %s; it's kept for %q",
+			name, sel.Sel.Name, quoteAll(candidates), importPaths[0],
+		)
+	}
+}
+
+// selectorCandidates returns import paths which the selector can refer to: the packages which export it or which
+// exports are unknown
+func selectorCandidates(exports astutil.PackageExports, importPaths []string, selector string) []string {
+	var candidates []string
+	for _, importPath := range importPaths {
+		names, ok := exports[importPath]
+		if _, exported := names[selector]; !ok || exported {
+			candidates = append(candidates, importPath)
+		}
+	}
+
+	return candidates
+}
+
+// quoteAll returns quoted import paths separated by comma
+func quoteAll(importPaths []string) string {
+	quoted := make([]string, 0, len(importPaths))
+	for _, importPath := range importPaths {
+		quoted = append(quoted, strconv.Quote(importPath))
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// collisionPriority returns the position of import's group in the priority list(lower is higher priority)
+func (f *SourceFile) collisionPriority(importPath string) int {
+	group := importGroup(f.projectName, f.companyPackagePrefixes, importPath)
+	for i, g := range f.collisionPriorities {
+		if g == group {
+			return i
+		}
+	}
+
+	return len(f.collisionPriorities)
+}
+
+// collisionAlias returns an alias prefixed with parent path element, like "customlog" for "example.com/custom/log",
+// or with "std" for top-level std packages, like "stdlog". Numeric suffix is added if the alias is already in use.
+func collisionAlias(file *ast.File, importPath, name string, importNames map[string]int) string {
+	base := renderAliasTemplate(aliasTemplateParent+aliasTemplateName, importPath, name)
+	if _, ok := std.StdPackages[importPath]; ok && !strings.Contains(importPath, "/") {
+		base = "std" + base
+	}
+	if !token.IsIdentifier(base) || base == name {
+		base = sanitizeIdentifier(name)
+	}
+
+	alias := base
+	for i := 2; importNames[alias] > 0 || astutil.IsNameDeclared(file, alias) || alias == name; i++ {
+		alias = base + strconv.Itoa(i)
+	}
+
+	return alias
+}
+
+func isExportedOnlyBy(exports astutil.PackageExports, importPaths []string, importPath, selector string) bool {
+	for _, p := range importPaths {
+		_, ok := exports[p][selector]
+		if p == importPath && !ok {
+			return false
+		}
+		if p != importPath && ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,196 @@
+package reviser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringToCanonicalAliases(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		aliases string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "success",
+			args: args{aliases: "k8s.io/api/core/v1=corev1, k8s.io/api/apps/v1 = appsv1,"},
+			want: map[string]string{
+				"k8s.io/api/core/v1": "corev1",
+				"k8s.io/api/apps/v1": "appsv1",
+			},
+		},
+		{
+			name:    "missing alias",
+			args:    args{aliases: "k8s.io/api/core/v1"},
+			wantErr: `invalid canonical alias "k8s.io/api/core/v1", expected format is "path=alias"`,
+		},
+		{
+			name:    "invalid alias",
+			args:    args{aliases: "k8s.io/api/core/v1=core-v1"},
+			wantErr: `invalid canonical alias "k8s.io/api/core/v1=core-v1", expected format is "path=alias"`,
+		},
+		{
+			name:    "conflicting aliases",
+			args:    args{aliases: "k8s.io/api/core/v1=corev1,k8s.io/api/core/v1=kapi"},
+			wantErr: `conflicting canonical aliases for "k8s.io/api/core/v1": "corev1" and "kapi"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := StringToCanonicalAliases(tt.args.aliases)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateAliasTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		tpl     string
+		wantErr string
+	}{
+		{
+			name: "success",
+			tpl:  "{{parent}}{{version}}",
+		},
+		{
+			name:    "without placeholders",
+			tpl:     "alias",
+			wantErr: `alias template "alias" has no placeholders, use {{name}}, {{parent}} or {{version}}`,
+		},
+		{
+			name:    "unknown placeholder",
+			tpl:     "{{parent}}{{major}}",
+			wantErr: `unknown placeholder "{{major}}" in alias template "{{parent}}{{major}}"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateAliasTemplate(tt.tpl)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRenderAliasTemplate(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		tpl        string
+		importPath string
+		name       string
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "k8s versioned package",
+			args: args{tpl: "{{parent}}{{version}}", importPath: "k8s.io/api/apps/v1", name: "v1"},
+			want: "appsv1",
+		},
+		{
+			name: "k8s beta versioned package",
+			args: args{tpl: "{{parent}}{{version}}", importPath: "k8s.io/api/batch/v1beta1", name: "v1beta1"},
+			want: "batchv1beta1",
+		},
+		{
+			name: "module major version",
+			args: args{tpl: "{{name}}{{version}}", importPath: "github.com/go-pg/pg/v9", name: "pg"},
+			want: "pgv9",
+		},
+		{
+			name: "parent with dash",
+			args: args{tpl: "{{parent}}{{name}}", importPath: "github.com/go-pg/pg/v9", name: "pg"},
+			want: "gopgpg",
+		},
+		{
+			name: "without version",
+			args: args{tpl: "{{parent}}{{name}}", importPath: "github.com/sirupsen/log", name: "log"},
+			want: "sirupsenlog",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, renderAliasTemplate(tt.args.tpl, tt.args.importPath, tt.args.name))
+		})
+	}
+}
+
+func TestStringToCollisionPriority(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		priority string
+		want     ImportsOrders
+		wantErr  string
+	}{
+		{
+			name:     "default",
+			priority: "",
+			want:     ImportsOrders{StdImportsOrder, ProjectImportsOrder, CompanyImportsOrder, GeneralImportsOrder},
+		},
+		{
+			name:     "partial",
+			priority: "general,std",
+			want:     ImportsOrders{GeneralImportsOrder, StdImportsOrder, ProjectImportsOrder, CompanyImportsOrder},
+		},
+		{
+			name:     "unknown group",
+			priority: "std,blanked",
+			wantErr:  `unknown collision priority group type: "blanked"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := StringToCollisionPriority(tt.priority)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
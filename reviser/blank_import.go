@@ -0,0 +1,69 @@
+package reviser
+
+import (
+	"sort"
+	"strings"
+)
+
+// restoreBlankImportsOrder puts order-sensitive blank imports of the sorted group back in their original relative
+// order. Other imports of the group keep their sorted positions. Imports are marked to be split with an empty line(see
+// commentsMetadata.BreakBefore) where the original order is not sorted, otherwise gofmt will sort them again.
+//
+// Ex.: `_ "b/driver"`, `_ "a/wrapper"`
+// -----
+// to
+// -----
+// _ "b/driver"
+//
+// _ "a/wrapper"
+func (f *SourceFile) restoreBlankImportsOrder(
+	imports []string,
+	importsWithMetadata map[string]*commentsMetadata,
+) []string {
+	if !f.shouldPreserveBlankImportsOrder {
+		return imports
+	}
+
+	var (
+		slots     []int
+		sensitive []string
+	)
+	for i, imprt := range imports {
+		if !f.isOrderSensitiveBlankImport(imprt) {
+			continue
+		}
+		slots = append(slots, i)
+		sensitive = append(sensitive, imprt)
+	}
+
+	if len(sensitive) < 2 {
+		return imports
+	}
+
+	sort.SliceStable(sensitive, func(i, j int) bool {
+		return importsWithMetadata[sensitive[i]].Pos < importsWithMetadata[sensitive[j]].Pos
+	})
+
+	for i, slot := range slots {
+		imports[slot] = sensitive[i]
+	}
+
+	var prevPath string
+	for _, imprt := range sensitive {
+		path := skipPackageAlias(imprt)
+		if prevPath != "" && path < prevPath {
+			importsWithMetadata[imprt].BreakBefore = true
+		}
+		prevPath = path
+	}
+
+	return imports
+}
+
+func (f *SourceFile) isOrderSensitiveBlankImport(imprt string) bool {
+	if !strings.HasPrefix(imprt, "_ ") {
+		return false
+	}
+
+	return len(f.blankImportsOrderPaths) == 0 || isPathInPackages(skipPackageAlias(imprt), f.blankImportsOrderPaths)
+}
@@ -0,0 +1,57 @@
+package reviser
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+const cgoImportPath = `"C"`
+
+func isCgoImportSpec(importSpec *ast.ImportSpec) bool {
+	value := importSpec.Path.Value
+	// trailing comment may be a part of the value after splitCgoImports
+	return value == cgoImportPath || strings.HasPrefix(value, cgoImportPath+" ")
+}
+
+// splitCgoImports removes `import "C"` from the declaration with other imports and returns it as a separate
+// declaration, so the cgo preamble(doc comment) stays right above `import "C"`
+func splitCgoImports(dd *ast.GenDecl) []ast.Decl {
+	var (
+		specs    []ast.Spec
+		cgoDecls []ast.Decl
+	)
+	for _, spec := range dd.Specs {
+		importSpec := spec.(*ast.ImportSpec)
+		if !isCgoImportSpec(importSpec) {
+			specs = append(specs, spec)
+			continue
+		}
+
+		// trailing comment is printed together with the import, otherwise it's printed inside of the declaration
+		// where the import was
+		if importSpec.Comment != nil {
+			importSpec.Path = &ast.BasicLit{
+				ValuePos: importSpec.Path.Pos(),
+				Kind:     token.STRING,
+				Value:    cgoImportPath + " " + commentGroupText(importSpec.Comment),
+			}
+			importSpec.Comment = nil
+		}
+
+		cgoDecls = append(cgoDecls, &ast.GenDecl{
+			Doc:    importSpec.Doc,
+			TokPos: importSpec.Pos(),
+			Tok:    token.IMPORT,
+			Specs:  []ast.Spec{importSpec},
+		})
+	}
+
+	if len(cgoDecls) == 0 {
+		return nil
+	}
+
+	dd.Specs = specs
+
+	return cgoDecls
+}
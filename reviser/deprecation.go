@@ -0,0 +1,49 @@
+package reviser
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"sort"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+)
+
+const deprecatedImportCategory = "deprecated-import"
+
+// checkDeprecatedImports reports imports of packages which documentation has `Deprecated:` paragraph. Documentation of
+// std packages and module dependencies is read offline from $GOROOT, vendor/ directory and the module cache.
+func (f *SourceFile) checkDeprecatedImports(
+	fset *token.FileSet,
+	file *ast.File,
+	importsWithMetadata map[string]*commentsMetadata,
+) {
+	if !f.shouldCheckDeprecatedImports {
+		return
+	}
+
+	imports := make([]string, 0, len(importsWithMetadata))
+	for imprt := range importsWithMetadata {
+		imports = append(imports, imprt)
+	}
+	sort.Slice(imports, func(i, j int) bool {
+		return importsWithMetadata[imports[i]].Pos < importsWithMetadata[imports[j]].Pos
+	})
+
+	importPaths := make([]string, 0, len(imports))
+	for _, imprt := range imports {
+		importPaths = append(importPaths, skipPackageAlias(imprt))
+	}
+
+	deprecations, err := astutil.LoadPackageDeprecations(filepath.Dir(f.filePath), importPaths)
+	if err != nil {
+		f.report(fset, file.Package, packageLoadCategory, "failed to load package documentation: %s", err)
+	}
+
+	for _, imprt := range imports {
+		pkg := skipPackageAlias(imprt)
+		if message, ok := deprecations[pkg]; ok {
+			f.report(fset, importsWithMetadata[imprt].Pos, deprecatedImportCategory, "package %q is deprecated: %s", pkg, message)
+		}
+	}
+}
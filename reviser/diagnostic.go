@@ -0,0 +1,144 @@
+package reviser
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// Diagnostic is a problem in the source file which is reported instead of being fixed
+type Diagnostic struct {
+	Pos      token.Position
+	Category string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Pos, d.Message)
+}
+
+// Diagnostics returns problems which were found by the last Fix call
+func (f *SourceFile) Diagnostics() []Diagnostic {
+	diagnostics := make([]Diagnostic, len(f.diagnostics))
+	copy(diagnostics, f.diagnostics)
+	return diagnostics
+}
+
+func (f *SourceFile) report(fset *token.FileSet, pos token.Pos, category, format string, args ...interface{}) {
+	position := fset.Position(pos)
+	if f.positions != nil {
+		position = f.positions.position(position)
+	}
+
+	f.diagnostics = append(f.diagnostics, Diagnostic{
+		Pos:      position,
+		Category: category,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// positionMap maps positions of the rewritten content onto the original content, so diagnostics which are found after
+// the content is rewritten and parsed again point to the source which is read by the user
+type positionMap struct {
+	// lines are lines of the original content by lines of the rewritten content
+	lines []int
+	// lineStarts are offsets of the lines of the original content
+	lineStarts []int
+}
+
+func newPositionMap(original, rewritten []byte) *positionMap {
+	originalLines := strings.Split(string(original), "\n")
+	matches := matchLines(originalLines, strings.Split(string(rewritten), "\n"))
+
+	lineStarts := make([]int, 0, len(originalLines))
+	var offset int
+	for _, line := range originalLines {
+		lineStarts = append(lineStarts, offset)
+		offset += len(line) + 1
+	}
+
+	return &positionMap{lines: matches, lineStarts: lineStarts}
+}
+
+// position returns the position in the original content. Lines which were added or changed by the rewrite are mapped
+// to the beginning of the original line where they are placed.
+func (m *positionMap) position(pos token.Position) token.Position {
+	if pos.Line < 1 || pos.Line > len(m.lines) {
+		return pos
+	}
+
+	line := m.lines[pos.Line-1]
+	if line == 0 {
+		line = 1
+		for i := pos.Line - 2; i >= 0; i-- {
+			if m.lines[i] != 0 {
+				line = min(m.lines[i]+1, len(m.lineStarts))
+				break
+			}
+		}
+		pos.Column = 1
+	}
+
+	pos.Line = line
+	pos.Offset = m.lineStarts[line-1] + pos.Column - 1
+
+	return pos
+}
+
+// matchLines returns the line number of a for every line of b, or 0 if the line was added to b. Lines are matched
+// with the shortest edit script of Myers' diff algorithm.
+func matchLines(a, b []string) []int {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+
+		var found bool
+		for k := -d; k <= d && !found; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			found = x >= n && y >= m
+		}
+		if found {
+			break
+		}
+	}
+
+	matches := make([]int, m)
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		k := x - y
+
+		prevK, startX := k-1, prev[offset+k-1]+1
+		if k == -d || (k != d && prev[offset+k-1] < prev[offset+k+1]) {
+			prevK, startX = k+1, prev[offset+k+1]
+		}
+
+		for x > startX {
+			x, y = x-1, y-1
+			matches[y] = x + 1
+		}
+		x = prev[offset+prevK]
+		y = x - prevK
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		matches[y] = x + 1
+	}
+
+	return matches
+}
@@ -0,0 +1,70 @@
+package reviser
+
+import (
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchLines(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want []int
+	}{
+		{
+			name: "equal",
+			a:    []string{"a", "b"},
+			b:    []string{"a", "b"},
+			want: []int{1, 2},
+		},
+		{
+			name: "inserted lines",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"x", "a", "y", "z", "b", "c"},
+			want: []int{0, 1, 0, 0, 2, 3},
+		},
+		{
+			name: "changed and removed lines",
+			a:    []string{"a", "b", "c", "d"},
+			b:    []string{"a", "x", "d"},
+			want: []int{1, 0, 4},
+		},
+		{
+			name: "empty",
+			a:    nil,
+			b:    []string{"a"},
+			want: []int{0},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, matchLines(tt.a, tt.b))
+		})
+	}
+}
+
+func TestPositionMap_Position(t *testing.T) {
+	t.Parallel()
+
+	positions := newPositionMap([]byte("a\nbb\ncc\n"), []byte("a\nx\ny\nbb\ncc\n"))
+
+	assert.Equal(
+		t,
+		token.Position{Filename: "a.go", Offset: 3, Line: 2, Column: 2},
+		positions.position(token.Position{Filename: "a.go", Offset: 7, Line: 4, Column: 2}),
+	)
+	assert.Equal(
+		t,
+		token.Position{Filename: "a.go", Offset: 2, Line: 2, Column: 1},
+		positions.position(token.Position{Filename: "a.go", Offset: 4, Line: 3, Column: 1}),
+	)
+}
@@ -7,9 +7,12 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 
-	"golang.org/x/exp/slices"
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
 )
 
 type walkCallbackFunc = func(hasChanged bool, path string, content []byte) error
@@ -33,11 +36,13 @@ type SourceDir struct {
 	dir             string
 	isRecursive     bool
 	excludePatterns []string // see filepath.Match
+	workers         int
+	diagnostics     []Diagnostic
 }
 
 var defaultExcludes = []string{".git", ".idea", ".vscode"}
 
-func NewSourceDir(projectName string, path string, isRecursive bool, excludes string) *SourceDir {
+func NewSourceDir(projectName string, path string, isRecursive bool, excludes string, options ...SourceDirOption) *SourceDir {
 	patterns := make([]string, 0)
 
 	// get the absolute path
@@ -68,12 +73,18 @@ func NewSourceDir(projectName string, path string, isRecursive bool, excludes st
 			}
 		}
 	}
-	return &SourceDir{
+	d := &SourceDir{
 		projectName:     projectName,
 		dir:             absPath,
 		isRecursive:     isRecursive,
 		excludePatterns: patterns,
+		workers:         runtime.GOMAXPROCS(0),
 	}
+	for _, option := range options {
+		option(d)
+	}
+
+	return d
 }
 
 func (d *SourceDir) Fix(options ...SourceFileOption) error {
@@ -82,7 +93,8 @@ func (d *SourceDir) Fix(options ...SourceFileOption) error {
 	if !ok {
 		return ErrPathIsNotDir
 	}
-	err := filepath.WalkDir(d.dir, d.walk(
+	d.diagnostics = nil
+	err := d.fixFiles(
 		func(hasChanged bool, path string, content []byte) error {
 			if !hasChanged {
 				return nil
@@ -94,7 +106,7 @@ func (d *SourceDir) Fix(options ...SourceFileOption) error {
 			return nil
 		},
 		options...,
-	))
+	)
 	if err != nil {
 		return fmt.Errorf("failed to walk dif: %w", err)
 	}
@@ -112,7 +124,8 @@ func (d *SourceDir) Find(options ...SourceFileOption) (*UnformattedCollection, e
 	if !ok {
 		return nil, ErrPathIsNotDir
 	}
-	err := filepath.WalkDir(d.dir, d.walk(
+	d.diagnostics = nil
+	err := d.fixFiles(
 		func(hasChanged bool, path string, content []byte) error {
 			if !hasChanged {
 				return nil
@@ -121,7 +134,7 @@ func (d *SourceDir) Find(options ...SourceFileOption) (*UnformattedCollection, e
 			return nil
 		},
 		options...,
-	))
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to walk dif: %w", err)
 	}
@@ -133,7 +146,118 @@ func (d *SourceDir) Find(options ...SourceFileOption) (*UnformattedCollection, e
 	return newUnformattedCollection(badFormattedCollection), nil
 }
 
-func (d *SourceDir) walk(callback walkCallbackFunc, options ...SourceFileOption) fs.WalkDirFunc {
+// fixFiles fixes Go files of the directory on the pool of workers. Results are passed to the callback in the order of
+// the directory walk, so the output doesn't depend on the number of workers.
+func (d *SourceDir) fixFiles(callback walkCallbackFunc, options ...SourceFileOption) error {
+	var paths []string
+	err := filepath.WalkDir(d.dir, d.walk(func(path string) {
+		paths = append(paths, path)
+	}))
+	if err != nil {
+		return err
+	}
+
+	cache := NewPackageImportsCache()
+	d.preloadPackageImports(cache, paths, options...)
+	options = append([]SourceFileOption{WithPackageImportsCache(cache)}, options...)
+
+	results := make([]chan fileResult, len(paths))
+	for i := range results {
+		results[i] = make(chan fileResult, 1)
+	}
+
+	jobs := make(chan int)
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for i := range paths {
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	workers := min(max(d.workers, 1), max(len(paths), 1))
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				sourceFile := NewSourceFile(d.projectName, paths[i])
+				content, _, hasChanged, err := sourceFile.Fix(options...)
+				results[i] <- fileResult{
+					content:     content,
+					hasChanged:  hasChanged,
+					diagnostics: sourceFile.Diagnostics(),
+					err:         err,
+				}
+			}
+		}()
+	}
+
+	for i, path := range paths {
+		result := <-results[i]
+		if result.err != nil {
+			return fmt.Errorf("failed to fix %s: %w", path, result.err)
+		}
+		d.diagnostics = append(d.diagnostics, result.diagnostics...)
+		if err := callback(result.hasChanged, path, result.content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// preloadPackageImports loads packages of the files' directories in batches, if the options require names of the
+// imported packages
+func (d *SourceDir) preloadPackageImports(cache *PackageImportsCache, paths []string, options ...SourceFileOption) {
+	probe := &SourceFile{}
+	for _, option := range options {
+		_ = option(probe)
+	}
+	if !probe.usesPackageImports() {
+		return
+	}
+
+	var dirs []string
+	seen := map[string]struct{}{}
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		if _, ok := seen[dir]; !ok {
+			seen[dir] = struct{}{}
+			dirs = append(dirs, dir)
+		}
+	}
+
+	contexts := probe.buildContexts
+	if len(contexts) == 0 {
+		contexts = []astutil.BuildContext{astutil.DefaultBuildContext()}
+	}
+	for _, ctx := range contexts {
+		cache.Preload(dirs, ctx)
+	}
+}
+
+type fileResult struct {
+	content     []byte
+	hasChanged  bool
+	diagnostics []Diagnostic
+	err         error
+}
+
+func (d *SourceDir) walk(collect func(path string)) fs.WalkDirFunc {
 	return func(path string, dirEntry fs.DirEntry, err error) error {
 		if !d.isRecursive && dirEntry.IsDir() && filepath.Base(d.dir) != dirEntry.Name() {
 			return filepath.SkipDir
@@ -142,16 +266,38 @@ func (d *SourceDir) walk(callback walkCallbackFunc, options ...SourceFileOption)
 			return filepath.SkipDir
 		}
 		if isGoFile(path) && !dirEntry.IsDir() && !d.isExcluded(path) {
-			content, _, hasChange, err := NewSourceFile(d.projectName, path).Fix(options...)
-			if err != nil {
-				return fmt.Errorf("failed to fix %s: %w", path, err)
-			}
-			return callback(hasChange, path, content)
+			collect(path)
 		}
 		return nil
 	}
 }
 
+// Diagnostics returns problems which were found in the files of the directory by the last Fix or Find call
+func (d *SourceDir) Diagnostics() []Diagnostic {
+	diagnostics := make([]Diagnostic, len(d.diagnostics))
+	copy(diagnostics, d.diagnostics)
+	return diagnostics
+}
+
+// IsExcluded checks if the file or any of its parent directories inside the directory is excluded. It's used to
+// apply excludes to files which are not found by the directory walk(ex.: files changed in git). Relative paths are
+// resolved against the directory.
+func (d *SourceDir) IsExcluded(path string) bool {
+	absPath := path
+	if !filepath.IsAbs(path) {
+		absPath = filepath.Join(d.dir, path)
+	}
+
+	for p := absPath; ; p = filepath.Dir(p) {
+		if d.isExcluded(p) {
+			return true
+		}
+		if p == d.dir || filepath.Dir(p) == p {
+			return false
+		}
+	}
+}
+
 func (d *SourceDir) isExcluded(path string) bool {
 	var absPath string
 	if filepath.IsAbs(path) {
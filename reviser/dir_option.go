@@ -0,0 +1,17 @@
+package reviser
+
+import "runtime"
+
+// SourceDirOption is an option of SourceDir
+type SourceDirOption func(d *SourceDir)
+
+// WithWorkers sets the number of files which are fixed concurrently. Results don't depend on the number of workers.
+// GOMAXPROCS is used by default or if n is less than 1.
+func WithWorkers(n int) SourceDirOption {
+	return func(d *SourceDir) {
+		if n < 1 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		d.workers = n
+	}
+}
@@ -1,12 +1,14 @@
 package reviser
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const sep = string(os.PathSeparator)
@@ -196,6 +198,53 @@ func TestSourceDir_IsExcluded(t *testing.T) {
 	}
 }
 
+func TestSourceDir_IsExcluded_Parents(t *testing.T) {
+	tests := []struct {
+		name     string
+		excludes string
+		testPath string
+		want     bool
+	}{
+		{
+			name:     "file",
+			excludes: "test.go",
+			testPath: "test.go",
+			want:     true,
+		},
+		{
+			name:     "parent dir",
+			excludes: "test/",
+			testPath: "test/a/b.go",
+			want:     true,
+		},
+		{
+			name:     "parent dir wildcard",
+			excludes: "proto/*",
+			testPath: "proto/api/api.pb.go",
+			want:     true,
+		},
+		{
+			name:     "default excludes",
+			excludes: "",
+			testPath: ".git/hooks/a.go",
+			want:     true,
+		},
+		{
+			name:     "not excluded",
+			excludes: "test/",
+			testPath: "other/test.go",
+			want:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			excluded := NewSourceDir("project", "project", true, test.excludes).IsExcluded(test.testPath)
+			assert.Equal(tt, test.want, excluded)
+		})
+	}
+}
+
 func TestSourceDir_Find(t *testing.T) {
 	testFile := "testdata/dir/dir1/file1.go"
 
@@ -268,6 +317,81 @@ func main() {
 	}
 }
 
+func TestSourceDir_Diagnostics(t *testing.T) {
+	testFile := "testdata/dir/dir1/file1.go"
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(testFile), os.ModePerm))
+	require.NoError(t, os.WriteFile(testFile, []byte(`package dir1
+
+import (
+	_ "embed"
+)
+`), os.ModePerm))
+	defer os.Remove(testFile)
+
+	sourceDir := NewSourceDir("testdata", "testdata/dir", true, "")
+	_, err := sourceDir.Find(WithImportHygieneRules(nil))
+	require.NoError(t, err)
+
+	rootPath, err := os.Getwd()
+	require.NoError(t, err)
+
+	diagnostics := sourceDir.Diagnostics()
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, filepath.Join(rootPath, testFile), diagnostics[0].Pos.Filename)
+	assert.Equal(t, 4, diagnostics[0].Pos.Line)
+	assert.Equal(t, blankImportCategory, diagnostics[0].Category)
+}
+
+func TestSourceDir_Find_WithWorkers(t *testing.T) {
+	dir := t.TempDir()
+
+	var want []string
+	var wantDiagnostics []string
+	for i := 0; i < 20; i++ {
+		fileName := filepath.Join(dir, fmt.Sprintf("file%02d.go", i))
+		content := "package dir\n\nimport (\n\t_ \"embed\"\n)\n"
+		if i%3 == 0 {
+			content = "package dir\n\nimport (\n\t\"strings\"\n\n\t\"fmt\"\n)\n\nvar _ = fmt.Sprint(strings.ToLower(\"\"))\n"
+			want = append(want, fileName)
+		} else {
+			wantDiagnostics = append(wantDiagnostics, fileName)
+		}
+		require.NoError(t, os.WriteFile(fileName, []byte(content), 0o644))
+	}
+
+	for _, workers := range []int{0, 1, 4, 32} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			sourceDir := NewSourceDir("dir", dir, false, "", WithWorkers(workers))
+			files, err := sourceDir.Find(WithImportHygieneRules(nil))
+			require.NoError(t, err)
+			assert.Equal(t, want, files.List())
+
+			var diagnostics []string
+			for _, diagnostic := range sourceDir.Diagnostics() {
+				diagnostics = append(diagnostics, diagnostic.Pos.Filename)
+			}
+			assert.Equal(t, wantDiagnostics, diagnostics)
+		})
+	}
+}
+
+func TestSourceDir_Fix_WithWorkersStopsOnFirstError(t *testing.T) {
+	dir := t.TempDir()
+
+	for i := 0; i < 10; i++ {
+		content := "package dir\n\nimport (\n\t\"strings\"\n\n\t\"fmt\"\n)\n\nvar _ = fmt.Sprint(strings.ToLower(\"\"))\n"
+		if i == 3 || i == 7 {
+			content = "package dir\n\nimport (\n"
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%02d.go", i)), []byte(content), 0o644))
+	}
+
+	err := NewSourceDir("dir", dir, false, "", WithWorkers(4)).Fix()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "file03.go"))
+}
+
 func TestUnformattedCollection_List(t *testing.T) {
 	tests := []struct {
 		name    string
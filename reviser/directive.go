@@ -0,0 +1,172 @@
+package reviser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+const (
+	directivePrefix      = "//goimports-reviser:"
+	skipDirective        = directivePrefix + "skip"
+	keepOrderDirective   = directivePrefix + "keep-order"
+	groupDirectivePrefix = directivePrefix + "group="
+
+	directiveCategory = "directive"
+)
+
+// hasSkipDirective checks if the file has `//goimports-reviser:skip` comment before the first declaration
+func hasSkipDirective(file *ast.File) bool {
+	end := file.End()
+	if len(file.Decls) > 0 {
+		end = file.Decls[0].Pos()
+	}
+
+	for _, commentGroup := range file.Comments {
+		if commentGroup.Pos() >= end {
+			break
+		}
+		if hasDirective(commentGroup, skipDirective) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasKeepOrderDirective checks if the import declaration has `//goimports-reviser:keep-order` comment in its doc.
+// Imports of such declaration are kept as is: they are not sorted, grouped or combined with other declarations.
+func HasKeepOrderDirective(dd *ast.GenDecl) bool {
+	return dd.Tok == token.IMPORT && hasDirective(dd.Doc, keepOrderDirective)
+}
+
+// importGroupDirective returns the group from `//goimports-reviser:group=<group>` comment of the import
+func importGroupDirective(importSpec *ast.ImportSpec) (ImportsOrder, bool) {
+	for _, commentGroup := range []*ast.CommentGroup{importSpec.Doc, importSpec.Comment} {
+		if commentGroup == nil {
+			continue
+		}
+		for _, comment := range commentGroup.List {
+			if group, ok := strings.CutPrefix(strings.TrimSpace(comment.Text), groupDirectivePrefix); ok {
+				return ImportsOrder(strings.TrimSpace(group)), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// keepOrderImportSpecs returns imports of the declarations with `//goimports-reviser:keep-order` directive
+func keepOrderImportSpecs(file *ast.File) map[*ast.ImportSpec]struct{} {
+	specs := map[*ast.ImportSpec]struct{}{}
+	for _, decl := range file.Decls {
+		dd, ok := decl.(*ast.GenDecl)
+		if !ok || !HasKeepOrderDirective(dd) {
+			continue
+		}
+		for _, spec := range dd.Specs {
+			specs[spec.(*ast.ImportSpec)] = struct{}{}
+		}
+	}
+
+	return specs
+}
+
+// keepOrderDecl is the import declaration with `//goimports-reviser:keep-order` directive with its source
+type keepOrderDecl struct {
+	decl *ast.GenDecl
+	// specs are imports of the declaration as they are in the source
+	specs  []string
+	source []byte
+}
+
+// keepOrderDecls returns declarations with `//goimports-reviser:keep-order` directive of the file with their sources
+// in the content
+func keepOrderDecls(fset *token.FileSet, file *ast.File, content []byte) []keepOrderDecl {
+	var decls []keepOrderDecl
+	for _, decl := range file.Decls {
+		dd, ok := decl.(*ast.GenDecl)
+		if !ok || !HasKeepOrderDirective(dd) {
+			continue
+		}
+
+		start, end := fset.Position(dd.Pos()).Offset, fset.Position(dd.End()).Offset
+		decls = append(decls, keepOrderDecl{decl: dd, specs: importSpecStrings(dd), source: content[start:end]})
+	}
+
+	return decls
+}
+
+// isChanged checks if imports of the declaration were changed after its source was taken(ex.: an alias was set)
+func (d keepOrderDecl) isChanged() bool {
+	return !slices.Equal(d.specs, importSpecStrings(d.decl))
+}
+
+func importSpecStrings(dd *ast.GenDecl) []string {
+	specs := make([]string, 0, len(dd.Specs))
+	for _, spec := range dd.Specs {
+		importSpec := spec.(*ast.ImportSpec)
+		specs = append(specs, importSpec.Name.String()+" "+importSpec.Path.Value)
+	}
+
+	return specs
+}
+
+// restoreKeepOrderDecls replaces declarations with `//goimports-reviser:keep-order` directive in the formatted content
+// with their sources, so their imports are kept byte-for-byte: gofmt doesn't sort them. Declarations which imports were
+// changed are kept formatted. Only the spacing around declarations is changed by the formatting.
+func restoreKeepOrderDecls(content []byte, decls []keepOrderDecl) ([]byte, error) {
+	if len(decls) == 0 {
+		return content, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	formattedDecls := keepOrderDecls(fset, file, content)
+	if len(formattedDecls) != len(decls) {
+		return content, nil
+	}
+
+	result := content
+	for i := len(decls) - 1; i >= 0; i-- {
+		if decls[i].isChanged() {
+			continue
+		}
+
+		start := fset.Position(formattedDecls[i].decl.Pos()).Offset
+		end := fset.Position(formattedDecls[i].decl.End()).Offset
+		result = insertContent(result, start, end, string(decls[i].source))
+	}
+
+	return result, nil
+}
+
+func isValidDirectiveGroup(group ImportsOrder) bool {
+	switch group {
+	case StdImportsOrder, CompanyImportsOrder, ProjectImportsOrder,
+		GeneralImportsOrder, BlankedImportsOrder, DottedImportsOrder:
+		return true
+	}
+
+	return false
+}
+
+func hasDirective(commentGroup *ast.CommentGroup, directive string) bool {
+	if commentGroup == nil {
+		return false
+	}
+
+	for _, comment := range commentGroup.List {
+		if strings.TrimSpace(comment.Text) == directive {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package reviser
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+)
+
+// defaultDotImportsAllowlist is a list of packages which are designed to be dot-imported in test suites
+var defaultDotImportsAllowlist = []string{
+	"github.com/onsi/ginkgo",
+	"github.com/onsi/ginkgo/v2",
+	"github.com/onsi/gomega",
+}
+
+// eliminateDotImports converts dot imports into regular imports and qualifies every identifier which was resolved
+// through them. Imports from the allowlist and imports which exports can't be loaded are kept as is.
+func (f *SourceFile) eliminateDotImports(fset *token.FileSet, file *ast.File) error {
+	if !f.shouldEliminateDotImports {
+		return nil
+	}
+
+	var dotImports []*ast.ImportSpec
+	for _, importSpec := range file.Imports {
+		if importSpec.Name == nil || importSpec.Name.Name != "." {
+			continue
+		}
+		if isPathInPackages(strings.Trim(importSpec.Path.Value, `"`), f.dotImportsAllowlist) {
+			continue
+		}
+		dotImports = append(dotImports, importSpec)
+	}
+
+	if len(dotImports) == 0 {
+		return nil
+	}
+
+	importPaths := make([]string, 0, len(dotImports))
+	for _, importSpec := range dotImports {
+		importPaths = append(importPaths, strings.Trim(importSpec.Path.Value, `"`))
+	}
+
+	exports, err := f.loadPackageExports(fset, file, importPaths)
+	if err != nil {
+		return err
+	}
+
+	imp, err := f.typesImporter(fset, file, exports)
+	if err != nil {
+		return err
+	}
+
+	info := astutil.TypeCheckFile(fset, file, imp)
+	importNames := countImportNames(file, nil)
+
+	for i, importSpec := range dotImports {
+		imprt := importPaths[i]
+		if _, ok := exports[imprt]; !ok {
+			continue
+		}
+
+		pkg, err := imp.Import(imprt)
+		if err != nil {
+			continue
+		}
+
+		name := pkg.Name()
+		if importNames[name] > 0 || astutil.IsNameDeclared(file, name) {
+			name = collisionAlias(file, imprt, name, importNames)
+		}
+
+		astutil.QualifyDotImportUses(file, info, pkg, name)
+
+		pos := importSpec.Pos()
+		importSpec.Name = nil
+		if name != pkg.Name() {
+			importSpec.Name = &ast.Ident{NamePos: pos, Name: name}
+		}
+		importNames[name]++
+	}
+
+	return nil
+}
+
+// isPathInPackages checks if the import path is one of the paths or is a subpackage of them
+func isPathInPackages(importPath string, paths []string) bool {
+	for _, p := range paths {
+		if importPath == p || strings.HasPrefix(importPath, p+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,208 @@
+package reviser
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+)
+
+const duplicateImportCategory = "duplicate-import"
+
+// mergeDuplicateImports merges imports of the same path into a single import spec. Comments of merged specs are kept
+// together and uses of the extra alias are rewritten to the name of the kept import. Blank imports are merged into the
+// named import of the same path. Duplicates with different explicit aliases, or a dot import together with a named
+// one, are reported as conflicts and kept as is.
+func (f *SourceFile) mergeDuplicateImports(fset *token.FileSet, file *ast.File) error {
+	importsByPath := map[string][]*ast.ImportSpec{}
+	keepOrderSpecs := keepOrderImportSpecs(file)
+	var paths []string
+	for _, importSpec := range file.Imports {
+		imprt := strings.Trim(importSpec.Path.Value, `"`)
+		if _, ok := keepOrderSpecs[importSpec]; ok || imprt == "C" {
+			continue
+		}
+		if _, ok := importsByPath[imprt]; !ok {
+			paths = append(paths, imprt)
+		}
+		importsByPath[imprt] = append(importsByPath[imprt], importSpec)
+	}
+
+	removed := map[*ast.ImportSpec]struct{}{}
+	for _, imprt := range paths {
+		specs := importsByPath[imprt]
+		if len(specs) < 2 {
+			continue
+		}
+
+		keep, merged, err := f.mergeImportSpecs(fset, file, imprt, specs)
+		if err != nil {
+			return err
+		}
+
+		for _, importSpec := range merged {
+			keep.Doc = mergeCommentGroups(keep.Doc, importSpec.Doc)
+			keep.Comment = mergeCommentGroups(keep.Comment, importSpec.Comment)
+			removed[importSpec] = struct{}{}
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	file.Imports = removeImportSpecs(file.Imports, removed)
+	decls := make([]ast.Decl, 0, len(file.Decls))
+	for _, decl := range file.Decls {
+		dd, ok := decl.(*ast.GenDecl)
+		if !ok || dd.Tok != token.IMPORT {
+			decls = append(decls, decl)
+			continue
+		}
+
+		var specs []ast.Spec
+		for _, spec := range dd.Specs {
+			if _, ok := removed[spec.(*ast.ImportSpec)]; !ok {
+				specs = append(specs, spec)
+			}
+		}
+
+		// declaration without parens can't be printed without specs
+		if len(specs) == 0 {
+			continue
+		}
+		dd.Specs = specs
+		decls = append(decls, dd)
+	}
+	file.Decls = decls
+
+	return nil
+}
+
+// mergeImportSpecs returns the import spec which should be kept and specs which should be merged into it
+func (f *SourceFile) mergeImportSpecs(
+	fset *token.FileSet,
+	file *ast.File,
+	imprt string,
+	specs []*ast.ImportSpec,
+) (*ast.ImportSpec, []*ast.ImportSpec, error) {
+	var (
+		blanked, named []*ast.ImportSpec
+		names          []string
+		hasDotted      bool
+	)
+	for _, importSpec := range specs {
+		name := importSpec.Name.String()
+		switch name {
+		case "_":
+			blanked = append(blanked, importSpec)
+			continue
+		case ".":
+			hasDotted = true
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+		named = append(named, importSpec)
+	}
+
+	if len(named) == 0 {
+		return blanked[0], blanked[1:], nil
+	}
+
+	var aliases []string
+	for _, name := range names {
+		if name != "<nil>" {
+			aliases = append(aliases, name)
+		}
+	}
+
+	if len(aliases) > 1 || (hasDotted && len(names) > 1) {
+		sort.Strings(aliases)
+		f.report(
+			fset, specs[1].Pos(), duplicateImportCategory,
+			"import %q is duplicated with different names: %s", imprt, strings.Join(aliases, ", "),
+		)
+		return named[0], blanked, nil
+	}
+
+	keep := named[0]
+	if len(names) == 1 {
+		return keep, append(named[1:], blanked...), nil
+	}
+
+	// "fmt" and f "fmt": keep the import without alias and rename uses of the alias
+	var alias string
+	for _, importSpec := range named {
+		if importSpec.Name == nil {
+			keep = importSpec
+		} else {
+			alias = importSpec.Name.Name
+		}
+	}
+
+	packageImports, err := f.loadPackageImports(fset, file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name, ok := packageImports[imprt]
+	if !ok || astutil.IsNameDeclared(file, name) {
+		f.report(
+			fset, specs[1].Pos(), duplicateImportCategory,
+			"import %q is duplicated with alias %s which can't be merged", imprt, alias,
+		)
+		return named[0], blanked, nil
+	}
+
+	astutil.RenameImportUses(file, alias, name)
+
+	var merged []*ast.ImportSpec
+	for _, importSpec := range named {
+		if importSpec != keep {
+			merged = append(merged, importSpec)
+		}
+	}
+
+	return keep, append(merged, blanked...), nil
+}
+
+// mergeCommentGroups combines comments of both groups, comments with the same text are kept once
+func mergeCommentGroups(dst, src *ast.CommentGroup) *ast.CommentGroup {
+	if src == nil {
+		return dst
+	}
+	if dst == nil {
+		return src
+	}
+
+	result := &ast.CommentGroup{List: append([]*ast.Comment{}, dst.List...)}
+	for _, c := range src.List {
+		var exists bool
+		for _, existing := range result.List {
+			if existing.Text == c.Text {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			result.List = append(result.List, c)
+		}
+	}
+
+	return result
+}
+
+func removeImportSpecs(specs []*ast.ImportSpec, removed map[*ast.ImportSpec]struct{}) []*ast.ImportSpec {
+	var result []*ast.ImportSpec
+	for _, importSpec := range specs {
+		if _, ok := removed[importSpec]; !ok {
+			result = append(result, importSpec)
+		}
+	}
+
+	return result
+}
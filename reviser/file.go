@@ -2,6 +2,7 @@ package reviser
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/format"
@@ -17,31 +18,60 @@ import (
 	"strings"
 
 	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+	"github.com/incu6us/goimports-reviser/v3/pkg/cache"
 	"github.com/incu6us/goimports-reviser/v3/pkg/std"
 )
 
 const (
 	StandardInput        = "<standard-input>"
 	stringValueSeparator = ","
+	packageLoadCategory  = "package-load"
 )
 
-var (
-	codeGeneratedPattern = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
-)
+var codeGeneratedPattern = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
 
 // SourceFile main struct for fixing an existing code
 type SourceFile struct {
-	shouldRemoveUnusedImports      bool
-	shouldUseAliasForVersionSuffix bool
-	shouldFormatCode               bool
-	shouldSkipAutoGenerated        bool
-	shouldSeparateNamedImports     bool
-	hasSeparateSideEffectGroup     bool
-	companyPackagePrefixes         []string
-	importsOrders                  ImportsOrders
-
-	projectName string
-	filePath    string
+	shouldRemoveUnusedImports       bool
+	shouldUseAliasForVersionSuffix  bool
+	shouldRemoveRedundantAliases    bool
+	shouldFormatCode                bool
+	shouldSkipAutoGenerated         bool
+	shouldSeparateNamedImports      bool
+	hasSeparateSideEffectGroup      bool
+	companyPackagePrefixes          []string
+	importsOrders                   ImportsOrders
+	canonicalAliases                map[string]string
+	aliasTemplate                   string
+	shouldResolveNameCollisions     bool
+	collisionPriorities             ImportsOrders
+	shouldEliminateDotImports       bool
+	dotImportsAllowlist             []string
+	shouldCheckImportHygiene        bool
+	testingPackages                 []string
+	shouldKeepGroupHeaders          bool
+	groupHeaders                    map[ImportsOrder]string
+	shouldPreserveBlankImportsOrder bool
+	blankImportsOrderPaths          []string
+	shouldPreserveImportGroups      bool
+	shouldMoveStdImportsFirst       bool
+	buildContexts                   []astutil.BuildContext
+	packageNameResolver             astutil.PackageNameResolver
+	shouldAddMissingImports         bool
+	shouldModernize                 bool
+	shouldCheckDeprecatedImports    bool
+	shouldCheckMajorVersions        bool
+	shouldFixMajorVersions          bool
+	packageImportsCache             *PackageImportsCache
+	resultCache                     *cache.Cache
+	resultCacheSalt                 string
+	sourceContent                   []byte
+
+	projectName    string
+	filePath       string
+	packageImports astutil.PackageImports
+	diagnostics    []Diagnostic
+	positions      *positionMap
 }
 
 // NewSourceFile constructor
@@ -54,6 +84,7 @@ func NewSourceFile(projectName, filePath string) *SourceFile {
 
 // Fix is for revise imports and format the code. Returns formated content, original content, true if formatted content is different from original and error.
 func (f *SourceFile) Fix(options ...SourceFileOption) ([]byte, []byte, bool, error) {
+	f.diagnostics = nil
 	for _, option := range options {
 		err := option(f)
 		if err != nil {
@@ -63,64 +94,165 @@ func (f *SourceFile) Fix(options ...SourceFileOption) ([]byte, []byte, bool, err
 
 	var originalContent []byte
 	var err error
-	if f.filePath == StandardInput {
+	switch {
+	case f.sourceContent != nil:
+		originalContent = f.sourceContent
+	case f.filePath == StandardInput:
 		originalContent, err = io.ReadAll(os.Stdin)
-	} else {
+	default:
 		originalContent, err = os.ReadFile(f.filePath)
 	}
 	if err != nil {
 		return nil, originalContent, false, err
 	}
 
+	if f.resultCache == nil {
+		fixedContent, hasChanged, err := f.fix(originalContent)
+		return fixedContent, originalContent, hasChanged, err
+	}
+
+	key := f.resultCacheKey(originalContent)
+	if result, ok := f.cachedResult(key); ok {
+		f.diagnostics = result.Diagnostics
+		if !result.HasChanged {
+			return originalContent, originalContent, false, nil
+		}
+		return result.Content, originalContent, true, nil
+	}
+
+	fixedContent, hasChanged, err := f.fix(originalContent)
+	if err == nil {
+		f.cacheResult(key, fixedContent, hasChanged)
+	}
+
+	return fixedContent, originalContent, hasChanged, err
+}
+
+// fix revises imports of the content and formats the code. Returns formatted content and true if it's different from
+// the original content.
+func (f *SourceFile) fix(originalContent []byte) ([]byte, bool, error) {
+	f.positions = nil
 	fset := token.NewFileSet()
 
 	pf, err := parser.ParseFile(fset, f.filePath, originalContent, parser.ParseComments)
 	if err != nil {
 		if len(originalContent) == 0 {
-			return nil, originalContent, false, fmt.Errorf("file is empty and cannot be parsed as Go source, use -excludes flag to skip this file: %w", err)
+			return nil, false, fmt.Errorf("file is empty and cannot be parsed as Go source, use -excludes flag to skip this file: %w", err)
 		}
-		return nil, originalContent, false, fmt.Errorf("file has invalid Go source content, use -excludes flag to skip this file: %w", err)
+		return nil, false, fmt.Errorf("file has invalid Go source content, use -excludes flag to skip this file: %w", err)
 	}
 
-	if len(pf.Imports) == 1 && pf.Imports[0].Path.Value == `"C"` {
-		return originalContent, originalContent, false, nil
+	if f.shouldSkipAutoGenerated && isFileAutoGenerate(pf) {
+		return originalContent, false, nil
 	}
 
-	if f.shouldSkipAutoGenerated && isFileAutoGenerate(pf) {
-		return originalContent, originalContent, false, nil
+	if hasSkipDirective(pf) {
+		return originalContent, false, nil
 	}
 
-	importsWithMetadata, err := f.parseImports(pf)
+	// source is the content which the file was parsed from
+	source := originalContent
+
+	if content := f.addMissingImports(fset, pf, originalContent); len(content) != len(originalContent) {
+		source = content
+		fset = token.NewFileSet()
+		pf, err = parser.ParseFile(fset, f.filePath, content, parser.ParseComments)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to add missing imports: %w", err)
+		}
+		f.positions = newPositionMap(originalContent, content)
+	}
+
+	// checked after missing imports are added: a file with `import "C"` only can still require `_ "embed"`
+	if len(pf.Imports) == 1 && isCgoImportSpec(pf.Imports[0]) {
+		return originalContent, false, nil
+	}
+
+	if f.modernizeImports(pf) {
+		// the file is printed and parsed again, so added imports and rewritten uses get their positions
+		modernizedContent, err := generateFile(fset, pf)
+		if err != nil {
+			return nil, false, err
+		}
+
+		source = modernizedContent
+		fset = token.NewFileSet()
+		pf, err = parser.ParseFile(fset, f.filePath, modernizedContent, parser.ParseComments)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to modernize imports: %w", err)
+		}
+		f.positions = newPositionMap(originalContent, modernizedContent)
+	}
+
+	keepOrderDecls := keepOrderDecls(fset, pf, source)
+
+	f.checkMajorVersions(fset, pf)
+
+	if err := f.mergeDuplicateImports(fset, pf); err != nil {
+		return nil, false, err
+	}
+
+	if err := f.eliminateDotImports(fset, pf); err != nil {
+		return nil, false, err
+	}
+
+	if err := f.applyCanonicalAliases(fset, pf); err != nil {
+		return nil, false, err
+	}
+
+	if err := f.resolveNameCollisions(fset, pf); err != nil {
+		return nil, false, err
+	}
+
+	importsWithMetadata, err := f.parseImports(fset, pf)
 	if err != nil {
-		return nil, originalContent, false, err
+		return nil, false, err
 	}
 
-	groups := f.groupImports(
-		f.projectName,
-		f.companyPackagePrefixes,
-		importsWithMetadata,
+	f.checkImportHygiene(fset, pf)
+	f.checkDeprecatedImports(fset, pf, importsWithMetadata)
+
+	var (
+		imports [][]string
+		headers []string
 	)
+	if f.shouldPreserveImportGroups {
+		imports = f.preservedImportGroups(fset, pf, importsWithMetadata)
+	} else {
+		groups := f.groupImports(
+			f.projectName,
+			f.companyPackagePrefixes,
+			importsWithMetadata,
+		)
+		imports = f.importsOrders.sortImportsByOrder(groups)
+		headers = f.groupHeaderComments()
+	}
 
 	decls, ok := hasMultipleImportDecls(pf)
 	if ok {
 		pf.Decls = decls
 	}
 
-	f.fixImports(pf, groups, importsWithMetadata)
+	f.fixImports(pf, imports, headers, importsWithMetadata)
 
 	f.formatDecls(pf)
 
 	fixedImportsContent, err := generateFile(fset, pf)
 	if err != nil {
-		return nil, originalContent, false, err
+		return nil, false, err
 	}
 
 	formattedContent, err := format.Source(fixedImportsContent)
 	if err != nil {
-		return nil, originalContent, false, err
+		return nil, false, err
+	}
+
+	formattedContent, err = restoreKeepOrderDecls(formattedContent, keepOrderDecls)
+	if err != nil {
+		return nil, false, err
 	}
 
-	return formattedContent, originalContent, !bytes.Equal(originalContent, formattedContent), nil
+	return formattedContent, !bytes.Equal(originalContent, formattedContent), nil
 }
 
 func isFileAutoGenerate(pf *ast.File) bool {
@@ -185,77 +317,56 @@ func (f *SourceFile) groupImports(
 		dottedImports         []string
 	)
 
-	for imprt := range importsWithMetadata {
-		if f.importsOrders.hasBlankedImportOrder() && strings.HasPrefix(imprt, "_") {
+	for imprt, metadata := range importsWithMetadata {
+		pinnedGroup := metadata.Group
+
+		if f.importsOrders.hasBlankedImportOrder() &&
+			(pinnedGroup == BlankedImportsOrder || pinnedGroup == "" && strings.HasPrefix(imprt, "_")) {
 			blankedImports = append(blankedImports, imprt)
 			continue
 		}
 
-		if f.importsOrders.hasDottedImportOrder() && strings.HasPrefix(imprt, ".") {
+		if f.importsOrders.hasDottedImportOrder() &&
+			(pinnedGroup == DottedImportsOrder || pinnedGroup == "" && strings.HasPrefix(imprt, ".")) {
 			dottedImports = append(dottedImports, imprt)
 			continue
 		}
 
 		pkgWithoutAlias := skipPackageAlias(imprt)
-		values := strings.Split(imprt, " ")
+		isNamed := len(strings.Split(imprt, " ")) > 1 && f.shouldSeparateNamedImports
 
-		if _, ok := std.StdPackages[pkgWithoutAlias]; ok {
-			if f.shouldSeparateNamedImports {
-				if len(values) > 1 {
-					namedStdImports = append(namedStdImports, imprt)
-				} else {
-					stdImports = append(stdImports, imprt)
-				}
-				continue
-			}
-			stdImports = append(stdImports, imprt)
-			continue
+		group := importGroup(projectName, localPkgPrefixes, pkgWithoutAlias)
+		switch pinnedGroup {
+		case StdImportsOrder, GeneralImportsOrder, CompanyImportsOrder, ProjectImportsOrder:
+			group = pinnedGroup
 		}
 
-		var isLocalPackageFound bool
-		for _, localPackagePrefix := range localPkgPrefixes {
-			if strings.HasPrefix(pkgWithoutAlias, localPackagePrefix) && pkgWithoutAlias != projectName && !strings.HasPrefix(pkgWithoutAlias, projectName+"/") {
-				if f.shouldSeparateNamedImports {
-					if len(values) > 1 {
-						namedProjectLocalPkgs = append(namedProjectLocalPkgs, imprt)
-					} else {
-						projectLocalPkgs = append(projectLocalPkgs, imprt)
-					}
-					isLocalPackageFound = true
-					break
-				}
+		switch group {
+		case StdImportsOrder:
+			if isNamed {
+				namedStdImports = append(namedStdImports, imprt)
+			} else {
+				stdImports = append(stdImports, imprt)
+			}
+		case CompanyImportsOrder:
+			if isNamed {
+				namedProjectLocalPkgs = append(namedProjectLocalPkgs, imprt)
+			} else {
 				projectLocalPkgs = append(projectLocalPkgs, imprt)
-				isLocalPackageFound = true
-				break
 			}
-		}
-
-		if isLocalPackageFound {
-			continue
-		}
-
-		if pkgWithoutAlias == projectName || strings.HasPrefix(pkgWithoutAlias, projectName+"/") {
-			if f.shouldSeparateNamedImports {
-				if len(values) > 1 {
-					namedProjectImports = append(namedProjectImports, imprt)
-				} else {
-					projectImports = append(projectImports, imprt)
-				}
-				continue
+		case ProjectImportsOrder:
+			if isNamed {
+				namedProjectImports = append(namedProjectImports, imprt)
+			} else {
+				projectImports = append(projectImports, imprt)
 			}
-			projectImports = append(projectImports, imprt)
-			continue
-		}
-
-		if f.shouldSeparateNamedImports {
-			if len(values) > 1 {
+		default:
+			if isNamed {
 				namedGeneralImports = append(namedGeneralImports, imprt)
 			} else {
 				generalImports = append(generalImports, imprt)
 			}
-			continue
 		}
-		generalImports = append(generalImports, imprt)
 	}
 
 	sort.Strings(stdImports)
@@ -269,6 +380,16 @@ func (f *SourceFile) groupImports(
 	sort.Strings(namedProjectLocalPkgs)
 	sort.Strings(namedProjectImports)
 
+	stdImports = f.restoreBlankImportsOrder(stdImports, importsWithMetadata)
+	generalImports = f.restoreBlankImportsOrder(generalImports, importsWithMetadata)
+	projectLocalPkgs = f.restoreBlankImportsOrder(projectLocalPkgs, importsWithMetadata)
+	projectImports = f.restoreBlankImportsOrder(projectImports, importsWithMetadata)
+	blankedImports = f.restoreBlankImportsOrder(blankedImports, importsWithMetadata)
+	namedStdImports = f.restoreBlankImportsOrder(namedStdImports, importsWithMetadata)
+	namedGeneralImports = f.restoreBlankImportsOrder(namedGeneralImports, importsWithMetadata)
+	namedProjectLocalPkgs = f.restoreBlankImportsOrder(namedProjectLocalPkgs, importsWithMetadata)
+	namedProjectImports = f.restoreBlankImportsOrder(namedProjectImports, importsWithMetadata)
+
 	result := &groupsImports{
 		common: &common{
 			std:          stdImports,
@@ -286,6 +407,26 @@ func (f *SourceFile) groupImports(
 	return result
 }
 
+// importGroup returns the group(std, company, project or general) of the import path
+func importGroup(projectName string, localPkgPrefixes []string, pkg string) ImportsOrder {
+	if _, ok := std.StdPackages[pkg]; ok {
+		return StdImportsOrder
+	}
+
+	isProjectPackage := pkg == projectName || strings.HasPrefix(pkg, projectName+"/")
+	for _, localPackagePrefix := range localPkgPrefixes {
+		if strings.HasPrefix(pkg, localPackagePrefix) && !isProjectPackage {
+			return CompanyImportsOrder
+		}
+	}
+
+	if isProjectPackage {
+		return ProjectImportsOrder
+	}
+
+	return GeneralImportsOrder
+}
+
 func skipPackageAlias(pkg string) string {
 	values := strings.Split(pkg, " ")
 	if len(values) > 1 {
@@ -312,12 +453,55 @@ func isSingleCgoImport(dd *ast.GenDecl) bool {
 	if len(dd.Specs) != 1 {
 		return false
 	}
-	return dd.Specs[0].(*ast.ImportSpec).Path.Value == `"C"`
+	return isCgoImportSpec(dd.Specs[0].(*ast.ImportSpec))
+}
+
+// isSeparateImportDecl checks if the import declaration should be kept as is: `import "C"` or the declaration with
+// `//goimports-reviser:keep-order` directive
+func isSeparateImportDecl(dd *ast.GenDecl) bool {
+	return isSingleCgoImport(dd) || HasKeepOrderDirective(dd)
+}
+
+// separateImportDeclsComments returns comments of the import declarations which are kept as is(cgo preambles,
+// comments inside of keep-order declarations)
+func separateImportDeclsComments(file *ast.File) map[*ast.CommentGroup]struct{} {
+	var positions []*importPosition
+	for _, decl := range file.Decls {
+		dd, ok := decl.(*ast.GenDecl)
+		if !ok || dd.Tok != token.IMPORT || !isSeparateImportDecl(dd) {
+			continue
+		}
+
+		start := dd.Pos()
+		if dd.Doc != nil {
+			start = dd.Doc.Pos()
+		}
+		lastSpec := dd.Specs[len(dd.Specs)-1].(*ast.ImportSpec)
+		end := dd.End()
+		if isSingleCgoImport(dd) {
+			// value of the split `import "C"` may contain its trailing comment
+			end = lastSpec.Path.Pos() + token.Pos(len(cgoImportPath))
+		}
+		if lastSpec.Comment != nil && lastSpec.Comment.End() > end {
+			end = lastSpec.Comment.End()
+		}
+		positions = append(positions, &importPosition{Start: start, End: end})
+	}
+
+	comments := map[*ast.CommentGroup]struct{}{}
+	for _, comment := range file.Comments {
+		if isInImportsRange(comment, positions) {
+			comments[comment] = struct{}{}
+		}
+	}
+
+	return comments
 }
 
 func (f *SourceFile) fixImports(
 	file *ast.File,
-	groups *groupsImports,
+	imports [][]string,
+	headers []string,
 	commentsMetadata map[string]*commentsMetadata,
 ) {
 	var importsPositions []*importPosition
@@ -327,7 +511,7 @@ func (f *SourceFile) fixImports(
 			continue
 		}
 
-		if dd.Tok != token.IMPORT || isSingleCgoImport(dd) {
+		if dd.Tok != token.IMPORT || isSeparateImportDecl(dd) {
 			continue
 		}
 
@@ -338,15 +522,15 @@ func (f *SourceFile) fixImports(
 			},
 		)
 
-		imports := f.importsOrders.sortImportsByOrder(groups)
-		dd.Specs = rebuildImports(dd.Tok, commentsMetadata, imports)
+		dd.Specs = rebuildImports(dd.Tok, commentsMetadata, imports, headers)
 	}
 
 	clearImportDocs(file, importsPositions)
 	removeEmptyImportNode(file)
 }
 
-// hasMultipleImportDecls will return combined import declarations to single declaration
+// hasMultipleImportDecls will return combined import declarations to single declaration. `import "C"` is kept in
+// a separate declaration with its preamble.
 //
 // Ex.:
 // import "fmt"
@@ -362,40 +546,72 @@ func (f *SourceFile) fixImports(
 // )
 func hasMultipleImportDecls(f *ast.File) ([]ast.Decl, bool) {
 	importSpecs := make([]ast.Spec, 0, len(f.Imports))
+	keepOrderSpecs := keepOrderImportSpecs(f)
 	for _, importSpec := range f.Imports {
+		if _, ok := keepOrderSpecs[importSpec]; ok || isCgoImportSpec(importSpec) {
+			continue
+		}
 		importSpecs = append(importSpecs, importSpec)
 	}
 
 	var (
-		hasMultipleImportDecls   bool
-		isFirstImportDeclDefined bool
+		hasMultipleImportDecls bool
+		firstImportDecl        *ast.GenDecl
+		firstImportDeclIdx     int
+		// cgo and keep-order declarations which are placed between combined declarations
+		cgoDecls        []ast.Decl
+		pendingCgoDecls []ast.Decl
 	)
 
 	decls := make([]ast.Decl, 0, len(f.Decls))
 	for _, decl := range f.Decls {
 		dd, ok := decl.(*ast.GenDecl)
-		if !ok {
+		if !ok || dd.Tok != token.IMPORT {
 			decls = append(decls, decl)
 			continue
 		}
 
-		if dd.Tok != token.IMPORT || isSingleCgoImport(dd) {
-			decls = append(decls, dd)
+		if isSeparateImportDecl(dd) {
+			if firstImportDecl != nil {
+				pendingCgoDecls = append(pendingCgoDecls, dd)
+			} else {
+				decls = append(decls, dd)
+			}
 			continue
 		}
 
-		if isFirstImportDeclDefined {
+		if splitDecls := splitCgoImports(dd); len(splitDecls) > 0 {
 			hasMultipleImportDecls = true
-			storedGenDecl := decls[len(decls)-1].(*ast.GenDecl)
-			if storedGenDecl.Tok == token.IMPORT {
-				storedGenDecl.Rparen = dd.End()
-			}
+			cgoDecls = append(cgoDecls, splitDecls...)
+		}
+
+		if firstImportDecl != nil {
+			hasMultipleImportDecls = true
+			firstImportDecl.Rparen = dd.End()
+			cgoDecls = append(cgoDecls, pendingCgoDecls...)
+			pendingCgoDecls = nil
 			continue
 		}
 
 		dd.Specs = importSpecs
 		decls = append(decls, dd)
-		isFirstImportDeclDefined = true
+		firstImportDecl = dd
+		firstImportDeclIdx = len(decls) - 1
+	}
+
+	if firstImportDecl == nil {
+		return decls, hasMultipleImportDecls
+	}
+
+	rest := append(pendingCgoDecls, decls[firstImportDeclIdx+1:]...)
+	decls = append(decls[:firstImportDeclIdx], append(cgoDecls, firstImportDecl)...)
+	decls = append(decls, rest...)
+
+	// cgo and keep-order declarations are printed before the combined declaration. Otherwise, their comments will be
+	// printed inside of it, because positions of the rebuilt imports are unknown to the printer.
+	if len(cgoDecls) > 0 && firstImportDecl.Doc == nil {
+		// empty doc adds an empty line between declarations
+		firstImportDecl.Doc = &ast.CommentGroup{List: []*ast.Comment{}}
 	}
 
 	return decls, hasMultipleImportDecls
@@ -431,8 +647,16 @@ func removeEmptyImportNode(f *ast.File) {
 	}
 }
 
-func rebuildImports(tok token.Token, commentsMetadata map[string]*commentsMetadata, imports [][]string) []ast.Spec {
-	var specs []ast.Spec
+func rebuildImports(
+	tok token.Token,
+	commentsMetadata map[string]*commentsMetadata,
+	imports [][]string,
+	headers []string,
+) []ast.Spec {
+	var (
+		specs      []ast.Spec
+		prevHeader string
+	)
 
 	for i, group := range imports {
 		if i != 0 && len(group) != 0 && len(specs) != 0 {
@@ -440,9 +664,30 @@ func rebuildImports(tok token.Token, commentsMetadata map[string]*commentsMetada
 
 			specs = append(specs, spec)
 		}
-		for _, imprt := range group {
+
+		header := groupHeader(group, commentsMetadata)
+		if i < len(headers) && headers[i] != "" && len(group) != 0 {
+			// named imports of the same group share the header
+			if headers[i] != prevHeader {
+				header = headers[i]
+			} else {
+				header = ""
+			}
+			prevHeader = headers[i]
+		}
+
+		for j, imprt := range group {
+			if metadata, ok := commentsMetadata[imprt]; ok && metadata != nil && metadata.BreakBefore && j != 0 {
+				specs = append(specs, &ast.ImportSpec{Path: &ast.BasicLit{Value: "", Kind: token.STRING}})
+			}
+
+			value := importWithComment(imprt, commentsMetadata)
+			if j == 0 && header != "" {
+				value = header + "\n" + value
+			}
+
 			spec := &ast.ImportSpec{
-				Path: &ast.BasicLit{Value: importWithComment(imprt, commentsMetadata), Kind: tok},
+				Path: &ast.BasicLit{Value: value, Kind: tok},
 			}
 			specs = append(specs, spec)
 		}
@@ -451,16 +696,103 @@ func rebuildImports(tok token.Token, commentsMetadata map[string]*commentsMetada
 	return specs
 }
 
+// groupHeader returns the header comment for the group of imports. If imports of several original groups are in the
+// group, the header of the import which was declared first is used.
+func groupHeader(group []string, importsWithMetadata map[string]*commentsMetadata) string {
+	var header *commentsMetadata
+	for _, imprt := range group {
+		metadata, ok := importsWithMetadata[imprt]
+		if !ok || metadata == nil || metadata.Header == nil {
+			continue
+		}
+		if header == nil || metadata.Pos < header.Pos {
+			header = metadata
+		}
+	}
+
+	if header == nil {
+		return ""
+	}
+
+	return commentGroupText(header.Header)
+}
+
+// importGroupHeaders returns header comments of the import groups(separated by an empty line) of the declaration mapped
+// to the first import of the group. Header is a comment which is not attached to any import or the doc comment of the
+// first import in the group.
+func importGroupHeaders(fset *token.FileSet, file *ast.File, dd *ast.GenDecl) map[*ast.ImportSpec]*ast.CommentGroup {
+	if !dd.Lparen.IsValid() {
+		return nil
+	}
+
+	var docs []*ast.CommentGroup
+	for _, spec := range dd.Specs {
+		if doc := spec.(*ast.ImportSpec).Doc; doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+
+	// free-floating comments inside of the declaration, except trailing comments of the imports
+	var floating []*ast.CommentGroup
+	for _, comment := range file.Comments {
+		if comment.Pos() <= dd.Lparen || comment.End() >= dd.Rparen {
+			continue
+		}
+
+		line := fset.Position(comment.Pos()).Line
+		isAttached := false
+		for _, spec := range dd.Specs {
+			importSpec := spec.(*ast.ImportSpec)
+			if importSpec.Doc == comment || fset.Position(importSpec.End()).Line == line {
+				isAttached = true
+				break
+			}
+		}
+		if !isAttached {
+			floating = append(floating, comment)
+		}
+	}
+
+	headers := map[*ast.ImportSpec]*ast.CommentGroup{}
+	prevLine := fset.Position(dd.Lparen).Line
+	for _, spec := range dd.Specs {
+		importSpec := spec.(*ast.ImportSpec)
+
+		start := importSpec.Pos()
+		if importSpec.Doc != nil {
+			start = importSpec.Doc.Pos()
+		}
+
+		var header *ast.CommentGroup
+		for _, comment := range floating {
+			if fset.Position(comment.Pos()).Line > prevLine && comment.End() < start {
+				header = mergeCommentGroups(header, comment)
+			}
+		}
+
+		isGroupStart := fset.Position(start).Line > prevLine+1 || prevLine == fset.Position(dd.Lparen).Line
+		if header == nil && isGroupStart && importSpec.Doc != nil {
+			header = importSpec.Doc
+		}
+		if header != nil {
+			headers[importSpec] = header
+		}
+
+		prevLine = fset.Position(importSpec.End()).Line
+	}
+
+	return headers
+}
+
 func clearImportDocs(f *ast.File, importsPositions []*importPosition) {
 	importsComments := make([]*ast.CommentGroup, 0, len(f.Comments))
+	keptComments := separateImportDeclsComments(f)
 
 	for _, comment := range f.Comments {
-		for _, importPosition := range importsPositions {
-			if importPosition.IsInRange(comment) {
-				continue
-			}
-			importsComments = append(importsComments, comment)
+		if _, ok := keptComments[comment]; !ok && isInImportsRange(comment, importsPositions) {
+			continue
 		}
+		importsComments = append(importsComments, comment)
 	}
 
 	if len(f.Imports) > 0 {
@@ -468,91 +800,286 @@ func clearImportDocs(f *ast.File, importsPositions []*importPosition) {
 	}
 }
 
+func isInImportsRange(comment *ast.CommentGroup, importsPositions []*importPosition) bool {
+	for _, importPosition := range importsPositions {
+		if importPosition.IsInRange(comment) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func importWithComment(imprt string, commentsMetadata map[string]*commentsMetadata) string {
-	var comment string
+	var comments []string
 	commentGroup, ok := commentsMetadata[imprt]
 	if ok && commentGroup != nil && commentGroup.Comment != nil {
 		for _, c := range commentGroup.Comment.List {
-			comment += c.Text
+			comments = append(comments, c.Text)
 		}
 	}
+	comment := strings.Join(comments, " ")
+
+	if comment != "" {
+		imprt = fmt.Sprintf("%s %s", imprt, comment)
+	}
+
+	// doc comment is printed on the lines above the import
+	if ok && commentGroup != nil && commentGroup.Doc != nil {
+		imprt = commentGroupText(commentGroup.Doc) + "\n" + imprt
+	}
 
-	if comment == "" {
-		return imprt
+	return imprt
+}
+
+func commentGroupText(commentGroup *ast.CommentGroup) string {
+	lines := make([]string, 0, len(commentGroup.List))
+	for _, c := range commentGroup.List {
+		lines = append(lines, c.Text)
 	}
 
-	return fmt.Sprintf("%s %s", imprt, comment)
+	return strings.Join(lines, "\n")
 }
 
-func (f *SourceFile) parseImports(file *ast.File) (map[string]*commentsMetadata, error) {
+func (f *SourceFile) parseImports(fset *token.FileSet, file *ast.File) (map[string]*commentsMetadata, error) {
 	importsWithMetadata := map[string]*commentsMetadata{}
 
 	shouldRemoveUnusedImports := f.shouldRemoveUnusedImports
 	shouldUseAliasForVersionSuffix := f.shouldUseAliasForVersionSuffix
+	shouldRemoveRedundantAliases := f.shouldRemoveRedundantAliases
 
 	var packageImports map[string]string
 
-	if shouldRemoveUnusedImports || shouldUseAliasForVersionSuffix {
+	if shouldUseAliasForVersionSuffix || shouldRemoveRedundantAliases {
 		var err error
-		buildTag := astutil.ParseBuildTag(file)
-		packageImports, err = astutil.LoadPackageDependencies(filepath.Dir(f.filePath), buildTag)
-		if err != nil && buildTag != "" {
-			// Retry without build tag — files with custom build constraints
-			// (like tools.go with //+build tools) may cause go list conflicts
-			// when the file imports the project itself.
-			packageImports, err = astutil.LoadPackageDependencies(filepath.Dir(f.filePath), "")
+		packageImports, err = f.loadPackageImports(fset, file)
+		if err != nil {
+			return nil, err
 		}
+	}
+
+	var unusedImports map[*ast.ImportSpec]struct{}
+	if shouldRemoveUnusedImports {
+		var err error
+		unusedImports, err = f.unusedImports(fset, file)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	var importNames map[string]int
+	if shouldUseAliasForVersionSuffix && f.aliasTemplate != "" {
+		importNames = countImportNames(file, packageImports)
+	}
+
+	var packageNames map[string]int
+	if shouldRemoveRedundantAliases {
+		packageNames = countPackageNames(file, packageImports)
+	}
+
 	for _, decl := range file.Decls {
 		dd, ok := decl.(*ast.GenDecl)
 		if !ok {
 			continue
 		}
-		if isSingleCgoImport(dd) || dd.Tok != token.IMPORT {
+		if dd.Tok != token.IMPORT || isSeparateImportDecl(dd) {
 			continue
 		}
+
+		var groupHeaders map[*ast.ImportSpec]*ast.CommentGroup
+		if f.shouldKeepGroupHeaders {
+			groupHeaders = importGroupHeaders(fset, file, dd)
+		}
+
 		for _, spec := range dd.Specs {
 			importSpec := spec.(*ast.ImportSpec)
+			if isCgoImportSpec(importSpec) {
+				continue
+			}
 
-			if shouldRemoveUnusedImports && !astutil.UsesImport(
-				file, packageImports, strings.Trim(importSpec.Path.Value, `"`),
-			) {
+			if _, ok := unusedImports[importSpec]; ok {
 				continue
 			}
 
 			var importSpecStr string
 			if importSpec.Name != nil {
-				importSpecStr = strings.Join([]string{importSpec.Name.String(), importSpec.Path.Value}, " ")
+				if shouldRemoveRedundantAliases && f.isRedundantAlias(importSpec, packageImports, packageNames) {
+					importSpecStr = importSpec.Path.Value
+				} else {
+					importSpecStr = strings.Join([]string{importSpec.Name.String(), importSpec.Path.Value}, " ")
+				}
 			} else {
 				if shouldUseAliasForVersionSuffix {
-					importSpecStr = setAliasForVersionedImportSpec(importSpec, packageImports)
+					alias, ok, err := f.templatedAlias(fset, file, importSpec, packageImports, importNames)
+					if err != nil {
+						return nil, err
+					}
+					if ok {
+						importSpecStr = strings.Join([]string{alias, importSpec.Path.Value}, " ")
+					} else {
+						importSpecStr = setAliasForVersionedImportSpec(file, importSpec, packageImports)
+					}
 				} else {
 					importSpecStr = importSpec.Path.Value
 				}
 			}
 
-			importsWithMetadata[importSpecStr] = &commentsMetadata{
-				Doc:     importSpec.Doc,
+			metadata := &commentsMetadata{
+				Doc:     f.stripGroupHeaders(importSpec.Doc),
 				Comment: importSpec.Comment,
+				Pos:     importSpec.Pos(),
+			}
+			if header, ok := groupHeaders[importSpec]; ok {
+				metadata.Header = f.stripGroupHeaders(header)
+				if header == importSpec.Doc {
+					metadata.Doc = nil
+				}
+			}
+			if group, ok := importGroupDirective(importSpec); ok {
+				if isValidDirectiveGroup(group) {
+					metadata.Group = group
+				} else {
+					f.report(fset, importSpec.Pos(), directiveCategory, "unknown import group %q in directive", group)
+				}
 			}
+			importsWithMetadata[importSpecStr] = metadata
 		}
 	}
 
 	return importsWithMetadata, nil
 }
 
-func setAliasForVersionedImportSpec(importSpec *ast.ImportSpec, packageImports map[string]string) string {
+// loadPackageImports loads names of the packages imported by the file's package. The result is loaded once per SourceFile
+// (and once per package directory if PackageImportsCache is set).
+// Names are loaded with `go list` unless a package name resolver is set. Errors of the package(ex.: a broken file or a
+// missing dependency) are reported as diagnostics and names which were resolved are returned.
+func (f *SourceFile) loadPackageImports(fset *token.FileSet, file *ast.File) (astutil.PackageImports, error) {
+	if f.packageImports != nil {
+		return f.packageImports, nil
+	}
+
+	if f.packageNameResolver != nil {
+		f.packageImports = f.resolvePackageImports(fset, file)
+		return f.packageImports, nil
+	}
+
+	packageImports := astutil.PackageImports{}
+	reported := map[string]struct{}{}
+	for _, ctx := range f.fileBuildContexts(file) {
+		imports, err := f.loadPackageDependencies(ctx)
+		if err != nil && len(ctx.Tags) > 0 {
+			// Retry without build tags — files with custom build constraints
+			// (like tools.go with //go:build tools) may cause go list conflicts
+			// when the file imports the project itself.
+			imports, err = f.loadPackageDependencies(astutil.BuildContext{GOOS: ctx.GOOS, GOARCH: ctx.GOARCH})
+		}
+
+		var loadErr *astutil.PackageLoadError
+		if errors.As(err, &loadErr) {
+			for _, pkgErr := range loadErr.Errors {
+				if _, ok := reported[pkgErr.Error()]; ok {
+					continue
+				}
+				reported[pkgErr.Error()] = struct{}{}
+				f.report(fset, file.Package, packageLoadCategory, "failed to load package: %s", pkgErr)
+			}
+		} else if err != nil {
+			return nil, err
+		}
+
+		for imprt, name := range imports {
+			packageImports[imprt] = name
+		}
+	}
+
+	f.packageImports = packageImports
+
+	return packageImports, nil
+}
+
+// loadPackageDependencies loads imports of the file's package with `go list`. The shared cache is used if it's set, so
+// files of the same package don't load it again.
+func (f *SourceFile) loadPackageDependencies(ctx astutil.BuildContext) (astutil.PackageImports, error) {
+	if f.packageImportsCache != nil {
+		return f.packageImportsCache.Load(filepath.Dir(f.filePath), ctx)
+	}
+
+	return astutil.LoadPackageDependenciesForContext(filepath.Dir(f.filePath), ctx)
+}
+
+// usesPackageImports checks if the options require names of the imported packages which are loaded with `go list`
+func (f *SourceFile) usesPackageImports() bool {
+	if f.packageNameResolver != nil {
+		return false
+	}
+
+	return f.shouldUseAliasForVersionSuffix || f.shouldRemoveRedundantAliases || f.shouldResolveNameCollisions ||
+		len(f.canonicalAliases) > 0
+}
+
+// unusedImports returns imports which are unused in every build configuration of the file
+func (f *SourceFile) unusedImports(fset *token.FileSet, file *ast.File) (map[*ast.ImportSpec]struct{}, error) {
+	imp, err := f.typesImporter(fset, file, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var unused map[*ast.ImportSpec]struct{}
+	for _, ctx := range f.fileBuildContexts(file) {
+		ctxUnused := astutil.UnusedImports(fset, file, ctx.Importer(imp))
+		if unused == nil {
+			unused = ctxUnused
+			continue
+		}
+
+		for importSpec := range unused {
+			if _, ok := ctxUnused[importSpec]; !ok {
+				delete(unused, importSpec)
+			}
+		}
+	}
+
+	return unused, nil
+}
+
+// fileBuildContexts returns configured build configurations in which the file is built, with the custom tags required
+// by its constraint. Configured build configurations are returned as is if the file is not built in any of them.
+func (f *SourceFile) fileBuildContexts(file *ast.File) []astutil.BuildContext {
+	contexts := f.buildContexts
+	if len(contexts) == 0 {
+		contexts = []astutil.BuildContext{astutil.DefaultBuildContext()}
+	}
+
+	expr, err := astutil.ParseBuildConstraint(file)
+	if err != nil {
+		return contexts
+	}
+
+	var fileContexts []astutil.BuildContext
+	for _, ctx := range contexts {
+		if fileCtx, ok := ctx.ForFile(expr); ok {
+			fileContexts = append(fileContexts, fileCtx)
+		}
+	}
+	if len(fileContexts) == 0 {
+		return contexts
+	}
+
+	return fileContexts
+}
+
+func setAliasForVersionedImportSpec(
+	file *ast.File,
+	importSpec *ast.ImportSpec,
+	packageImports astutil.PackageImports,
+) string {
 	var importSpecStr string
 
 	imprt := strings.Trim(importSpec.Path.Value, `"`)
-	aliasName := packageImports[imprt]
+	aliasName, ok := resolvedPackageName(file, imprt, packageImports)
 
 	importSuffix := path.Base(imprt)
-	if importSuffix != aliasName {
+	if ok && importSuffix != aliasName {
 		importSpecStr = fmt.Sprintf("%s %s", aliasName, importSpec.Path.Value)
 	} else {
 		importSpecStr = importSpec.Path.Value
@@ -564,6 +1091,13 @@ func setAliasForVersionedImportSpec(importSpec *ast.ImportSpec, packageImports m
 type commentsMetadata struct {
 	Doc     *ast.CommentGroup
 	Comment *ast.CommentGroup
+	// Header is a comment of the import group which the import opened in the original source
+	Header *ast.CommentGroup
+	// Group is set by `//goimports-reviser:group=<group>` directive
+	Group ImportsOrder
+	Pos   token.Pos
+	// BreakBefore is set if the import must be separated from the previous import of its group with an empty line
+	BreakBefore bool
 }
 
 type importPosition struct {
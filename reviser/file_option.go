@@ -2,6 +2,9 @@ package reviser
 
 import (
 	"strings"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+	"github.com/incu6us/goimports-reviser/v3/pkg/cache"
 )
 
 // SourceFileOption is an int alias for options
@@ -22,6 +25,206 @@ func WithUsingAliasForVersionSuffix(f *SourceFile) error {
 	return nil
 }
 
+// WithRemovingRedundantAliases is an option to remove aliases which are equal to the real package name, like
+// `errors "errors"`. It is the inverse of WithUsingAliasForVersionSuffix.
+func WithRemovingRedundantAliases(f *SourceFile) error {
+	f.shouldRemoveRedundantAliases = true
+	return nil
+}
+
+// WithAliasTemplate sets template for aliases of versioned imports(ex.: "k8s.io/api/apps/v1") and imports with colliding
+// package names. Placeholders: {{name}} - package name, {{parent}} - path element before the package, {{version}} -
+// version suffix of the path. Ex.: "{{parent}}{{version}}" will set alias "appsv1". Works together with
+// WithUsingAliasForVersionSuffix.
+func WithAliasTemplate(tpl string) SourceFileOption {
+	return func(f *SourceFile) error {
+		if err := validateAliasTemplate(tpl); err != nil {
+			return err
+		}
+		f.aliasTemplate = tpl
+		return nil
+	}
+}
+
+// WithResolvingNameCollisions is an option to set aliases for imports with colliding package names. Groups in priority
+// keep their names first(std, project, company, general by default). Uses of the renamed packages are rewritten.
+func WithResolvingNameCollisions(priority ImportsOrders) SourceFileOption {
+	// defaults are resolved once, so the option can be applied to files concurrently
+	var err error
+	if len(priority) == 0 {
+		priority, err = StringToCollisionPriority(defaultCollisionPriority)
+	}
+
+	return func(f *SourceFile) error {
+		if err != nil {
+			return err
+		}
+		f.shouldResolveNameCollisions = true
+		f.collisionPriorities = priority
+		return nil
+	}
+}
+
+// WithDotImportsElimination is an option to convert dot imports into regular imports and qualify all identifiers which
+// were resolved through them. Dot imports of allowlist paths(and their subpackages) are kept. Default allowlist is used
+// if it's empty: github.com/onsi/ginkgo, github.com/onsi/ginkgo/v2, github.com/onsi/gomega.
+func WithDotImportsElimination(allowlist []string) SourceFileOption {
+	if len(allowlist) == 0 {
+		allowlist = defaultDotImportsAllowlist
+	}
+
+	return func(f *SourceFile) error {
+		f.shouldEliminateDotImports = true
+		f.dotImportsAllowlist = allowlist
+		return nil
+	}
+}
+
+// WithImportHygieneRules is an option to report blank imports without a justifying comment outside of main and test
+// packages, and testing-only packages imported from non-test files. Default list of testing packages is used if it's
+// empty: testing, github.com/stretchr/testify, github.com/golang/mock/gomock, go.uber.org/mock/gomock.
+// Violations are available with SourceFile.Diagnostics.
+func WithImportHygieneRules(testingPackages []string) SourceFileOption {
+	if len(testingPackages) == 0 {
+		testingPackages = defaultTestingPackages
+	}
+
+	return func(f *SourceFile) error {
+		f.shouldCheckImportHygiene = true
+		f.testingPackages = testingPackages
+		return nil
+	}
+}
+
+// WithKeepingGroupHeaders is an option to keep comments which open import groups(ex.: `// internal`) above the group
+// where their imports are placed after sorting. A comment which is not attached to any import, or the doc comment of the
+// first import after an empty line, is treated as a group header.
+func WithKeepingGroupHeaders(f *SourceFile) error {
+	f.shouldKeepGroupHeaders = true
+	return nil
+}
+
+// WithGroupHeaders is an option to put the header comment above every import group(key - group, value - comment).
+// Existing comments which are equal to one of the headers are replaced, so the result stays the same on the next run.
+func WithGroupHeaders(headers map[ImportsOrder]string) SourceFileOption {
+	return func(f *SourceFile) error {
+		f.groupHeaders = make(map[ImportsOrder]string, len(headers))
+		for group, header := range headers {
+			f.groupHeaders[group] = headerComment(header)
+		}
+		return nil
+	}
+}
+
+// WithPreservedBlankImportsOrder is an option to keep the original relative order of blank imports(`_ "pkg"`) inside of
+// their groups, ex.: when a driver must be registered before its wrapper. Only blank imports of paths(and their
+// subpackages) keep the order if paths are set, otherwise all blank imports.
+func WithPreservedBlankImportsOrder(paths []string) SourceFileOption {
+	return func(f *SourceFile) error {
+		f.shouldPreserveBlankImportsOrder = true
+		f.blankImportsOrderPaths = paths
+		return nil
+	}
+}
+
+// WithPreservedImportGroups is an option to keep groups of imports as they are in the source(groups are separated with
+// an empty line) and sort imports inside of each group only. Std imports are moved into the first group if
+// moveStdFirst is set.
+func WithPreservedImportGroups(moveStdFirst bool) SourceFileOption {
+	return func(f *SourceFile) error {
+		f.shouldPreserveImportGroups = true
+		f.shouldMoveStdImportsFirst = moveStdFirst
+		return nil
+	}
+}
+
+// WithBuildContexts is an option to set build configurations(OS, architecture and build tags) which are used to load
+// packages. Custom tags from the build constraint of the file are added automatically. Unused imports are removed only if
+// they are unused in every configuration where the file is built. Current $GOOS and $GOARCH are used by default.
+func WithBuildContexts(contexts []astutil.BuildContext) SourceFileOption {
+	return func(f *SourceFile) error {
+		f.buildContexts = contexts
+		return nil
+	}
+}
+
+// WithPackageNameResolver is an option to resolve names of the imported packages with the resolver instead of `go list`,
+// ex.: astutil.ChainResolver{astutil.VendorResolver{}, astutil.ModuleCacheResolver{}, astutil.HeuristicResolver{}}
+// works offline. Resolver errors are reported as diagnostics.
+func WithPackageNameResolver(resolver astutil.PackageNameResolver) SourceFileOption {
+	return func(f *SourceFile) error {
+		f.packageNameResolver = resolver
+		return nil
+	}
+}
+
+// WithAddingMissingImports is an option to add imports which are required by compiler directives, but are missing in
+// the file: `_ "embed"` for `//go:embed` and `_ "unsafe"` for `//go:linkname`
+func WithAddingMissingImports(f *SourceFile) error {
+	f.shouldAddMissingImports = true
+	return nil
+}
+
+// WithModernization is an option to migrate imports to std packages which are available for the Go version of go.mod:
+// golang.org/x/exp/slices and golang.org/x/exp/maps to slices and maps(go 1.21), io/ioutil to io and os(go 1.16).
+// Uses with compatible signatures are rewritten, the old import is kept if some uses have no std replacement.
+func WithModernization(f *SourceFile) error {
+	f.shouldModernize = true
+	return nil
+}
+
+// WithDeprecatedImportsCheck is an option to report imports of packages which documentation starts a paragraph with
+// `Deprecated:`. Documentation is read offline from $GOROOT, vendor/ directory and the module cache.
+func WithDeprecatedImportsCheck(f *SourceFile) error {
+	f.shouldCheckDeprecatedImports = true
+	return nil
+}
+
+// WithMajorVersionCheck is an option to report imports which major version doesn't match the module required by
+// go.mod(ex.: "github.com/foo/bar" while go.mod requires "github.com/foo/bar/v3") and files which import different major
+// versions of the same module
+func WithMajorVersionCheck(f *SourceFile) error {
+	f.shouldCheckMajorVersions = true
+	return nil
+}
+
+// WithMajorVersionFix is an option to rewrite imports to the major version of the module required by go.mod. Together
+// with WithUsingAliasForVersionSuffix the rewritten imports get the alias. Imports which can't be fixed(ex.: go.mod
+// requires several major versions of the module) are reported.
+func WithMajorVersionFix(f *SourceFile) error {
+	f.shouldFixMajorVersions = true
+	return nil
+}
+
+// WithPackageImportsCache is an option to share names of the imported packages between files of the same package
+// directory, so `go list` is called once per directory and build configuration. SourceDir sets it for every run.
+func WithPackageImportsCache(cache *PackageImportsCache) SourceFileOption {
+	return func(f *SourceFile) error {
+		f.packageImportsCache = cache
+		return nil
+	}
+}
+
+// WithResultCache is an option to reuse results of the files which were already fixed with the same options. The
+// result is looked up by the hash of the file content, options, go.mod, the list of std packages and salt, which should
+// be the version of the tool.
+func WithResultCache(c *cache.Cache, salt string) SourceFileOption {
+	return func(f *SourceFile) error {
+		f.resultCache = c
+		f.resultCacheSalt = salt
+		return nil
+	}
+}
+
+// WithSourceContent is an option to fix the content instead of reading the file(ex.: the content staged in git index).
+// The file path is still used to load the package of the file.
+func WithSourceContent(content []byte) SourceFileOption {
+	return func(f *SourceFile) error {
+		f.sourceContent = content
+		return nil
+	}
+}
+
 // WithCodeFormatting use to format the code
 func WithCodeFormatting(f *SourceFile) error {
 	f.shouldFormatCode = true
@@ -54,6 +257,15 @@ func WithSkipGeneratedFile(f *SourceFile) error {
 	return nil
 }
 
+// WithCanonicalAliases will enforce the alias for every import of the configured paths(key - import path, value - alias)
+// and rename all uses of the previous package name inside the file
+func WithCanonicalAliases(aliases map[string]string) SourceFileOption {
+	return func(f *SourceFile) error {
+		f.canonicalAliases = aliases
+		return nil
+	}
+}
+
 func WithSeparatedNamedImports(f *SourceFile) error {
 	f.shouldSeparateNamedImports = true
 	return nil
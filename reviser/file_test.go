@@ -8,6 +8,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
 )
 
 func TestSourceFile_Fix(t *testing.T) {
@@ -369,7 +371,7 @@ import (
 		},
 
 		{
-			name: "success with clear doc for import",
+			name: "success with doc for import",
 			args: args{
 				projectName: "github.com/incu6us/goimports-reviser",
 				filePath:    "./testdata/example.go",
@@ -391,6 +393,7 @@ import (
 import (
 	"fmt"
 
+	// test
 	"github.com/incu6us/goimports-reviser/testdata/innderpkg"
 )
 
@@ -637,6 +640,76 @@ import (
 #include <stdlib.h>
 */
 import "C"
+`,
+			wantChange: true,
+			wantErr:    false,
+		},
+		{
+			name: "preserves cgo import between import declarations",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/cgo_example.go",
+				fileContent: `package testdata
+
+import "fmt"
+
+// #include <stdlib.h>
+import "C"
+
+import (
+	"strings"
+	"errors"
+)
+`,
+			},
+			want: `package testdata
+
+// #include <stdlib.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+`,
+			wantChange: true,
+			wantErr:    false,
+		},
+		{
+			name: "preserves cgo import inside of import declaration",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/cgo_example.go",
+				fileContent: `package testdata
+
+import (
+	"strings"
+	/*
+	#cgo LDFLAGS: -lm
+	#include <math.h>
+	*/
+	"C" // cgo
+	"fmt"
+)
+
+// nolint:gomnd
+`,
+			},
+			want: `package testdata
+
+/*
+	#cgo LDFLAGS: -lm
+	#include <math.h>
+*/
+import "C" // cgo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// nolint:gomnd
 `,
 			wantChange: true,
 			wantErr:    false,
@@ -957,7 +1030,7 @@ func TestSourceFile_Fix_WithRemoveUnusedImports(t *testing.T) {
 
 import (
 	"fmt" //fmt package
-	"golang.org/x/exp/slices" //custom package
+	"golang.org/x/mod/semver" //custom package
 )
 
 // nolint:gomnd
@@ -981,6 +1054,41 @@ func main() {
 			wantErr:    false,
 		},
 
+		{
+			name: "remove unused import shadowed by local variable",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// nolint:gomnd
+func main(){
+	strings := []string{"a"}
+	fmt.Println(strings)
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	"fmt"
+)
+
+// nolint:gomnd
+func main() {
+	strings := []string{"a"}
+	fmt.Println(strings)
+}
+`,
+			wantChange: true,
+			wantErr:    false,
+		},
+
 		{
 			name: "remove unused import with alias",
 			args: args{
@@ -990,7 +1098,7 @@ func main() {
 
 import (
 	"fmt" //fmt package
-	p "golang.org/x/exp/slices" //p package
+	p "golang.org/x/mod/semver" //p package
 )
 
 // nolint:gomnd
@@ -1293,7 +1401,7 @@ func main() {
 import "C"
 import(
 	"fmt"
-	"golang.org/x/exp/slices"
+	"golang.org/x/mod/semver"
 	"strconv"
 )
 
@@ -1384,6 +1492,66 @@ import (
 	assert.Contains(t, string(got), `_ "example.com/nonexistent/tool"`)
 }
 
+func TestSourceFile_Fix_WithPackageLoadErrors(t *testing.T) {
+	// A broken sibling file and a missing dependency must not fail the whole file: resolved names are used, names of
+	// missing packages are assumed from the import path and imports which can't be checked are kept.
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(
+		filepath.Join(tmpDir, "go.mod"),
+		[]byte("module example.com/testproject\n\ngo 1.21\n"),
+		0o644,
+	))
+
+	require.NoError(t, os.WriteFile(
+		filepath.Join(tmpDir, "broken.go"),
+		[]byte("package testproject\n\nfunc broken() {\n"),
+		0o644,
+	))
+
+	fileContent := `package testproject
+
+import (
+	"fmt"
+	"strings"
+
+	"example.com/missing/go-nats/v2"
+	"example.com/missing/unused"
+)
+
+func main() {
+	fmt.Println(nats.Connect())
+}
+`
+	filePath := filepath.Join(tmpDir, "main.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(fileContent), 0o644))
+
+	sourceFile := NewSourceFile("example.com/testproject", filePath)
+	got, _, hasChange, err := sourceFile.Fix(WithRemovingUnusedImports, WithUsingAliasForVersionSuffix)
+	require.NoError(t, err)
+
+	assert.True(t, hasChange)
+	assert.Equal(t, `package testproject
+
+import (
+	"fmt"
+
+	nats "example.com/missing/go-nats/v2"
+	"example.com/missing/unused"
+)
+
+func main() {
+	fmt.Println(nats.Connect())
+}
+`, string(got))
+
+	diagnostics := sourceFile.Diagnostics()
+	require.NotEmpty(t, diagnostics)
+	for _, diagnostic := range diagnostics {
+		assert.Equal(t, packageLoadCategory, diagnostic.Category)
+	}
+}
+
 func TestSourceFile_Fix_WithAliasForVersionSuffix(t *testing.T) {
 	type args struct {
 		projectName string
@@ -1431,6 +1599,35 @@ func main() {
 			wantChange: true,
 			wantErr:    false,
 		},
+		{
+			name: "success without alias for guessed name which is not used",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"example.com/t1/missing/v2"
+)
+
+func main() {
+	v2.X()
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	"example.com/t1/missing/v2"
+)
+
+func main() {
+	v2.X()
+}
+`,
+			wantChange: false,
+			wantErr:    false,
+		},
 		{
 			name: "success with golang.org/x/exp/slices",
 			args: args{
@@ -2347,3 +2544,2590 @@ import (
 		})
 	}
 }
+
+func TestSourceFile_Fix_WithCanonicalAliases(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		aliases     map[string]string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		want       string
+		wantChange bool
+		wantErr    bool
+	}{
+		{
+			name: "success with renamed alias",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	kapi "k8s.io/api/core/v1"
+	"strings"
+)
+
+func main() {
+	_ = kapi.Pod{}
+	_ = strings.ToLower("A")
+}
+`,
+				aliases: map[string]string{"k8s.io/api/core/v1": "corev1"},
+			},
+			want: `package testdata
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func main() {
+	_ = corev1.Pod{}
+	_ = strings.ToLower("A")
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with unaliased import",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"strings"
+)
+
+func main() {
+	_ = strings.ToLower("A")
+}
+`,
+				aliases: map[string]string{"strings": "str"},
+			},
+			want: `package testdata
+
+import (
+	str "strings"
+)
+
+func main() {
+	_ = str.ToLower("A")
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with package name equal to alias",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"errors"
+	"strings"
+)
+
+func main() {
+	_ = errors.New(strings.ToLower("A"))
+}
+`,
+				aliases: map[string]string{"errors": "errors"},
+			},
+			want: `package testdata
+
+import (
+	"errors"
+	"strings"
+)
+
+func main() {
+	_ = errors.New(strings.ToLower("A"))
+}
+`,
+			wantChange: false,
+		},
+		{
+			name: "success with shadowed name",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+func main() {
+	_ = v1.Pod{}
+	func(v1 struct{ Pod int }) {
+		_ = v1.Pod
+	}(struct{ Pod int }{})
+}
+`,
+				aliases: map[string]string{"k8s.io/api/core/v1": "corev1"},
+			},
+			want: `package testdata
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+func main() {
+	_ = corev1.Pod{}
+	func(v1 struct{ Pod int }) {
+		_ = v1.Pod
+	}(struct{ Pod int }{})
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "error with alias declared in the file",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+var corev1 = v1.Pod{}
+`,
+				aliases: map[string]string{"k8s.io/api/core/v1": "corev1"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).
+				Fix(WithCanonicalAliases(tt.args.aliases))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantChange, hasChange)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithAliasTemplate(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		tpl         string
+	}
+	tests := []struct {
+		name            string
+		args            args
+		want            string
+		wantChange      bool
+		wantErr         bool
+		wantDiagnostics int
+	}{
+		{
+			name: "success with versioned package name",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"fmt"
+
+	"github.com/incu6us/goimports-reviser/v3/reviser/testdata/apps/v1"
+)
+
+func main() {
+	fmt.Println(v1.Deployment{})
+}
+`,
+				tpl: "{{parent}}{{version}}",
+			},
+			want: `package testdata
+
+import (
+	"fmt"
+
+	appsv1 "github.com/incu6us/goimports-reviser/v3/reviser/testdata/apps/v1"
+)
+
+func main() {
+	fmt.Println(appsv1.Deployment{})
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with colliding package names",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"log"
+
+	"github.com/incu6us/goimports-reviser/v3/reviser/testdata/custom/log"
+)
+`,
+				tpl: "{{parent}}{{name}}",
+			},
+			want: `package testdata
+
+import (
+	"log"
+
+	customlog "github.com/incu6us/goimports-reviser/v3/reviser/testdata/custom/log"
+)
+`,
+			wantChange: true,
+		},
+		{
+			name: "success without versioned or colliding names",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"fmt"
+)
+
+func main() {
+	fmt.Println()
+}
+`,
+				tpl: "{{parent}}{{version}}",
+			},
+			want: `package testdata
+
+import (
+	"fmt"
+)
+
+func main() {
+	fmt.Println()
+}
+`,
+			wantChange: false,
+		},
+		{
+			name: "error with invalid template",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+`,
+				tpl: "{{unknown}}",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).
+				Fix(WithUsingAliasForVersionSuffix, WithAliasTemplate(tt.args.tpl))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantChange, hasChange)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithRemovingRedundantAliases(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		want       string
+		wantChange bool
+		wantErr    bool
+	}{
+		{
+			name: "success with redundant aliases",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	errors "errors"
+	str "strings"
+
+	v1 "github.com/incu6us/goimports-reviser/v3/reviser/testdata/apps/v1"
+)
+
+var (
+	_ = errors.New(str.ToLower("A"))
+	_ = v1.Deployment{}
+)
+`,
+			},
+			want: `package testdata
+
+import (
+	"errors"
+	str "strings"
+
+	"github.com/incu6us/goimports-reviser/v3/reviser/testdata/apps/v1"
+)
+
+var (
+	_ = errors.New(str.ToLower("A"))
+	_ = v1.Deployment{}
+)
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with alias which resolves collision",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	stdlog "log"
+
+	log "github.com/incu6us/goimports-reviser/v3/reviser/testdata/custom/log"
+)
+
+func main() {
+	stdlog.Println("A")
+	log.Info("A")
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	stdlog "log"
+
+	log "github.com/incu6us/goimports-reviser/v3/reviser/testdata/custom/log"
+)
+
+func main() {
+	stdlog.Println("A")
+	log.Info("A")
+}
+`,
+			wantChange: false,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).
+				Fix(WithRemovingRedundantAliases)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantChange, hasChange)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithResolvingNameCollisions(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		priority    ImportsOrders
+	}
+	tests := []struct {
+		name       string
+		args       args
+		want       string
+		wantChange bool
+		wantErr    bool
+	}{
+		{
+			name: "success with std priority",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"log"
+
+	"github.com/incu6us/goimports-reviser/v3/reviser/testdata/custom/log"
+)
+
+func main() {
+	log.Println("A")
+	log.Info("A")
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	"log"
+
+	customlog "github.com/incu6us/goimports-reviser/v3/reviser/testdata/custom/log"
+)
+
+func main() {
+	log.Println("A")
+	customlog.Info("A")
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with project priority",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"log"
+
+	"github.com/incu6us/goimports-reviser/v3/reviser/testdata/custom/log"
+)
+
+func main() {
+	log.Println("A")
+	log.Info("A")
+}
+`,
+				priority: ImportsOrders{ProjectImportsOrder, StdImportsOrder},
+			},
+			want: `package testdata
+
+import (
+	stdlog "log"
+
+	"github.com/incu6us/goimports-reviser/v3/reviser/testdata/custom/log"
+)
+
+func main() {
+	stdlog.Println("A")
+	log.Info("A")
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success without collisions",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"log"
+)
+
+func main() {
+	log.Println("A")
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	"log"
+)
+
+func main() {
+	log.Println("A")
+}
+`,
+			wantChange: false,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).
+				Fix(WithResolvingNameCollisions(tt.args.priority))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantChange, hasChange)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithResolvingNameCollisionsAmbiguousSelectors(t *testing.T) {
+	const fileContent = `package testdata
+
+import (
+	"log"
+
+	"github.com/incu6us/goimports-reviser/v3/reviser/testdata/ambiguous/log"
+)
+
+func main() {
+	log.Println("A")
+	log.Info("A")
+}
+`
+	const want = `package testdata
+
+import (
+	"log"
+
+	ambiguouslog "github.com/incu6us/goimports-reviser/v3/reviser/testdata/ambiguous/log"
+)
+
+func main() {
+	log.Println("A")
+	ambiguouslog.Info("A")
+}
+`
+
+	tests := []struct {
+		name     string
+		resolver string
+	}{
+		{
+			name: "go list",
+		},
+		{
+			name:     "package name resolver",
+			resolver: "modcache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath, err := filepath.Abs("./testdata/example.go")
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(filePath, []byte(fileContent), 0o644))
+
+			options := []SourceFileOption{WithResolvingNameCollisions(nil)}
+			if tt.resolver != "" {
+				resolver, err := StringToPackageNameResolver(tt.resolver)
+				require.NoError(t, err)
+				options = append(options, WithPackageNameResolver(resolver))
+			}
+
+			sourceFile := NewSourceFile("github.com/incu6us/goimports-reviser/v3", filePath)
+			got, _, hasChange, err := sourceFile.Fix(options...)
+			require.NoError(t, err)
+
+			assert.True(t, hasChange)
+			assert.Equal(t, want, string(got))
+
+			diagnostics := sourceFile.Diagnostics()
+			require.Len(t, diagnostics, 1)
+			assert.Equal(t, nameCollisionCategory, diagnostics[0].Category)
+			assert.Equal(t, 10, diagnostics[0].Pos.Line)
+			assert.Equal(
+				t,
+				`log.Println is ambiguous, it can refer to "log", `+
+					`"github.com/incu6us/goimports-reviser/v3/reviser/testdata/ambiguous/log"; it's kept for "log"`,
+				diagnostics[0].Message,
+			)
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithDotImportsElimination(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		allowlist   []string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		want       string
+		wantChange bool
+		wantErr    bool
+	}{
+		{
+			name: "success with dot import",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"fmt"
+	. "strings"
+)
+
+type builder struct{}
+
+func main() {
+	fmt.Println(ToLower("A"), Repeat("a", 2))
+	var b = builder{}
+	func(ToUpper func(string) string) {
+		fmt.Println(ToUpper("a"), b)
+	}(ToLower)
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+type builder struct{}
+
+func main() {
+	fmt.Println(strings.ToLower("A"), strings.Repeat("a", 2))
+	var b = builder{}
+	func(ToUpper func(string) string) {
+		fmt.Println(ToUpper("a"), b)
+	}(strings.ToLower)
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with name which is already in use",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	. "errors"
+)
+
+func main() {
+	errors := []error{New("A")}
+	_ = errors
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	stderrors "errors"
+)
+
+func main() {
+	errors := []error{stderrors.New("A")}
+	_ = errors
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with allowlist",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	. "strings"
+)
+
+var _ = ToLower("A")
+`,
+				allowlist: []string{"strings"},
+			},
+			want: `package testdata
+
+import (
+	. "strings"
+)
+
+var _ = ToLower("A")
+`,
+			wantChange: false,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).
+				Fix(WithDotImportsElimination(tt.args.allowlist))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantChange, hasChange)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithImportHygieneRules(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+	}
+	tests := []struct {
+		name            string
+		args            args
+		wantDiagnostics []string
+	}{
+		{
+			name: "success with violations",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	_ "embed"
+	// for side effects
+	_ "net/http/pprof"
+	_ "image/png" // registers png decoder
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+`,
+			},
+			wantDiagnostics: []string{
+				`./testdata/example.go:4:2: blank import "embed" should have a comment justifying it`,
+				`./testdata/example.go:6:2: blank import "net/http/pprof" should have a comment justifying it`,
+				`./testdata/example.go:8:2: testing package "testing" should not be imported from non-test file example.go`,
+				`./testdata/example.go:10:2: testing package "github.com/stretchr/testify/assert" should not be imported from non-test file example.go`,
+			},
+		},
+		{
+			name: "success with keep-order and single import declarations",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+//goimports-reviser:keep-order
+import (
+	_ "os"
+	_ "errors" // registers errors
+)
+
+import _ "net/http/pprof"
+
+import "testing"
+`,
+			},
+			wantDiagnostics: []string{
+				`./testdata/example.go:5:2: blank import "os" should have a comment justifying it`,
+				`./testdata/example.go:9:8: blank import "net/http/pprof" should have a comment justifying it`,
+				`./testdata/example.go:11:8: testing package "testing" should not be imported from non-test file example.go`,
+			},
+		},
+		{
+			name: "success with main package",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package main
+
+import (
+	_ "embed"
+)
+`,
+			},
+		},
+		{
+			name: "success with test file",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example_test.go",
+				fileContent: `package testdata
+
+import (
+	_ "embed"
+	"testing"
+)
+`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			sourceFile := NewSourceFile(tt.args.projectName, tt.args.filePath)
+			_, _, _, err := sourceFile.Fix(WithImportHygieneRules(nil))
+			require.NoError(t, err)
+
+			var diagnostics []string
+			for _, diagnostic := range sourceFile.Diagnostics() {
+				diagnostics = append(diagnostics, diagnostic.String())
+			}
+			assert.Equal(t, tt.wantDiagnostics, diagnostics)
+		})
+	}
+
+	require.NoError(t, os.Remove("./testdata/example_test.go"))
+}
+
+func TestSourceFile_Fix_WithDeprecatedImportsCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(
+		filepath.Join(tmpDir, "go.mod"),
+		[]byte("module example.com/testproject\n\ngo 1.21\n\nrequire example.com/dep v1.0.0\n\nreplace example.com/dep => ./dep\n"),
+		0o644,
+	))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "dep", "old"), 0o755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(tmpDir, "dep", "old", "doc.go"),
+		[]byte("// Package old is old.\n//\n// Deprecated: use\n// example.com/dep/new instead.\npackage old\n\nfunc Do() {}\n"),
+		0o644,
+	))
+
+	fileContent := `package testproject
+
+import (
+	"fmt"
+
+	"example.com/dep/old"
+)
+
+func main() {
+	fmt.Println()
+	old.Do()
+}
+`
+	filePath := filepath.Join(tmpDir, "main.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(fileContent), 0o644))
+
+	sourceFile := NewSourceFile("example.com/testproject", filePath)
+	got, _, hasChange, err := sourceFile.Fix(WithDeprecatedImportsCheck)
+	require.NoError(t, err)
+
+	assert.False(t, hasChange)
+	assert.Equal(t, fileContent, string(got))
+
+	diagnostics := sourceFile.Diagnostics()
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, deprecatedImportCategory, diagnostics[0].Category)
+	assert.Equal(t, 6, diagnostics[0].Pos.Line)
+	assert.Equal(t, `package "example.com/dep/old" is deprecated: use example.com/dep/new instead.`, diagnostics[0].Message)
+}
+
+func TestSourceFile_Fix_WithMajorVersions(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(
+		filepath.Join(tmpDir, "go.mod"),
+		[]byte(`module example.com/testproject
+
+go 1.21
+
+require (
+	github.com/baz/qux v1.0.0
+	github.com/baz/qux/v2 v2.0.0
+	github.com/foo/bar/v3 v3.0.0
+)
+`),
+		0o644,
+	))
+
+	tests := []struct {
+		name            string
+		options         []SourceFileOption
+		fileContent     string
+		want            string
+		wantDiagnostics []string
+	}{
+		{
+			name:    "report mismatch",
+			options: []SourceFileOption{WithMajorVersionCheck},
+			fileContent: `package testproject
+
+import (
+	"github.com/foo/bar/pkg"
+)
+
+func main() {
+	pkg.Do()
+}
+`,
+			want: `package testproject
+
+import (
+	"github.com/foo/bar/pkg"
+)
+
+func main() {
+	pkg.Do()
+}
+`,
+			wantDiagnostics: []string{
+				`main.go:4:2: import "github.com/foo/bar/pkg" doesn't match the major version of module "github.com/foo/bar/v3" required by go.mod`,
+			},
+		},
+		{
+			name:    "fix mismatch",
+			options: []SourceFileOption{WithMajorVersionFix},
+			fileContent: `package testproject
+
+import (
+	"github.com/foo/bar/pkg"
+)
+
+func main() {
+	pkg.Do()
+}
+`,
+			want: `package testproject
+
+import (
+	"github.com/foo/bar/v3/pkg"
+)
+
+func main() {
+	pkg.Do()
+}
+`,
+		},
+		{
+			name: "fix mismatch with alias",
+			options: []SourceFileOption{
+				WithMajorVersionFix,
+				WithUsingAliasForVersionSuffix,
+				WithPackageNameResolver(astutil.HeuristicResolver{}),
+			},
+			fileContent: `package testproject
+
+import (
+	"github.com/foo/bar"
+)
+
+func main() {
+	bar.Do()
+}
+`,
+			want: `package testproject
+
+import (
+	bar "github.com/foo/bar/v3"
+)
+
+func main() {
+	bar.Do()
+}
+`,
+		},
+		{
+			name:    "report mixed major versions",
+			options: []SourceFileOption{WithMajorVersionCheck},
+			fileContent: `package testproject
+
+import (
+	"github.com/foo/bar/v2"
+	"github.com/foo/bar/v3/pkg"
+)
+
+func main() {
+	bar.Do()
+	pkg.Do()
+}
+`,
+			want: `package testproject
+
+import (
+	"github.com/foo/bar/v2"
+	"github.com/foo/bar/v3/pkg"
+)
+
+func main() {
+	bar.Do()
+	pkg.Do()
+}
+`,
+			wantDiagnostics: []string{
+				`main.go:4:2: import "github.com/foo/bar/v2" doesn't match the major version of module "github.com/foo/bar/v3" required by go.mod`,
+				`main.go:5:2: imports mix major versions of module "github.com/foo/bar": v2, v3`,
+			},
+		},
+		{
+			name:    "several required major versions",
+			options: []SourceFileOption{WithMajorVersionFix},
+			fileContent: `package testproject
+
+import (
+	"github.com/baz/qux/v3"
+)
+
+func main() {
+	qux.Do()
+}
+`,
+			want: `package testproject
+
+import (
+	"github.com/baz/qux/v3"
+)
+
+func main() {
+	qux.Do()
+}
+`,
+			wantDiagnostics: []string{
+				`main.go:4:2: import "github.com/baz/qux/v3" doesn't match any of major versions of module "github.com/baz/qux" required by go.mod: v0/v1, v2`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(tmpDir, "main.go")
+			require.NoError(t, os.WriteFile(filePath, []byte(tt.fileContent), 0o644))
+
+			sourceFile := NewSourceFile("example.com/testproject", filePath)
+			got, _, _, err := sourceFile.Fix(tt.options...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, string(got))
+
+			var diagnostics []string
+			for _, diagnostic := range sourceFile.Diagnostics() {
+				diagnostics = append(diagnostics, strings.TrimPrefix(diagnostic.String(), tmpDir+string(filepath.Separator)))
+			}
+			assert.Equal(t, tt.wantDiagnostics, diagnostics)
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithDuplicateImports(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+	}
+	tests := []struct {
+		name            string
+		args            args
+		want            string
+		wantChange      bool
+		wantDiagnostics []string
+	}{
+		{
+			name: "success with identical imports",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"fmt" // first
+	"strings"
+	"fmt" // second
+)
+
+func main() {
+	fmt.Println(strings.ToLower("A"))
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	"fmt" // first // second
+	"strings"
+)
+
+func main() {
+	fmt.Println(strings.ToLower("A"))
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with alias",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	f "fmt"
+	"fmt" // fmt package
+	_ "fmt"
+)
+
+func main() {
+	fmt.Println("A")
+	f.Println("B")
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	"fmt" // fmt package
+)
+
+func main() {
+	fmt.Println("A")
+	fmt.Println("B")
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with conflicting aliases",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser/v3",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	f "fmt"
+	ff "fmt"
+)
+
+func main() {
+	f.Println("A")
+	ff.Println("B")
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	f "fmt"
+	ff "fmt"
+)
+
+func main() {
+	f.Println("A")
+	ff.Println("B")
+}
+`,
+			wantChange: false,
+			wantDiagnostics: []string{
+				`./testdata/example.go:5:2: import "fmt" is duplicated with different names: f, ff`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			sourceFile := NewSourceFile(tt.args.projectName, tt.args.filePath)
+			got, _, hasChange, err := sourceFile.Fix()
+			require.NoError(t, err)
+
+			var diagnostics []string
+			for _, diagnostic := range sourceFile.Diagnostics() {
+				diagnostics = append(diagnostics, diagnostic.String())
+			}
+
+			assert.Equal(t, tt.wantChange, hasChange)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, tt.wantDiagnostics, diagnostics)
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithImportDocs(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		options     []SourceFileOption
+	}
+
+	tests := []struct {
+		name       string
+		args       args
+		want       string
+		wantChange bool
+	}{
+		{
+			name: "success with doc moved together with import",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package main
+
+import (
+	// for side effects
+	_ "net/http/pprof"
+	"strings"
+
+	// formatting
+	// of the output
+	"fmt" // fmt package
+)
+
+func main() {
+	fmt.Println(strings.ToLower("A"))
+}
+`,
+			},
+			want: `package main
+
+import (
+	// formatting
+	// of the output
+	"fmt" // fmt package
+	"strings"
+	// for side effects
+	_ "net/http/pprof"
+)
+
+func main() {
+	fmt.Println(strings.ToLower("A"))
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with group headers",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package main
+
+import (
+	// internal
+	"github.com/incu6us/goimports-reviser/pkg/b"
+	"github.com/incu6us/goimports-reviser/pkg/a"
+
+	// std
+
+	"strings"
+	// formatting
+	"fmt"
+)
+
+func main() {
+	fmt.Println(strings.ToLower(a.A + b.B))
+}
+`,
+				options: []SourceFileOption{WithKeepingGroupHeaders},
+			},
+			want: `package main
+
+import (
+	// std
+	// formatting
+	"fmt"
+	"strings"
+
+	// internal
+	"github.com/incu6us/goimports-reviser/pkg/a"
+	"github.com/incu6us/goimports-reviser/pkg/b"
+)
+
+func main() {
+	fmt.Println(strings.ToLower(a.A + b.B))
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with group headers is idempotent",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package main
+
+import (
+	// std
+	"fmt"
+	"strings"
+
+	// internal
+	"github.com/incu6us/goimports-reviser/pkg/a"
+	"github.com/incu6us/goimports-reviser/pkg/b"
+)
+
+func main() {
+	fmt.Println(strings.ToLower(a.A + b.B))
+}
+`,
+				options: []SourceFileOption{WithKeepingGroupHeaders},
+			},
+			want: `package main
+
+import (
+	// std
+	"fmt"
+	"strings"
+
+	// internal
+	"github.com/incu6us/goimports-reviser/pkg/a"
+	"github.com/incu6us/goimports-reviser/pkg/b"
+)
+
+func main() {
+	fmt.Println(strings.ToLower(a.A + b.B))
+}
+`,
+			wantChange: false,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).Fix(tt.args.options...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantChange, hasChange)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithGroupHeaders(t *testing.T) {
+	headers := map[ImportsOrder]string{
+		StdImportsOrder:     "Standard library",
+		GeneralImportsOrder: "Third party",
+		ProjectImportsOrder: "// Project",
+	}
+
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		options     []SourceFileOption
+	}
+
+	tests := []struct {
+		name       string
+		args       args
+		want       string
+		wantChange bool
+	}{
+		{
+			name: "success with group headers",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package main
+
+import (
+	"github.com/incu6us/goimports-reviser/pkg"
+	"strings"
+	// for errors
+	"golang.org/x/exp/errors"
+	"fmt"
+)
+
+func main() {
+	fmt.Println(strings.ToLower(pkg.A), errors.New)
+}
+`,
+				options: []SourceFileOption{WithGroupHeaders(headers)},
+			},
+			want: `package main
+
+import (
+	// Standard library
+	"fmt"
+	"strings"
+
+	// Third party
+	// for errors
+	"golang.org/x/exp/errors"
+
+	// Project
+	"github.com/incu6us/goimports-reviser/pkg"
+)
+
+func main() {
+	fmt.Println(strings.ToLower(pkg.A), errors.New)
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with existing group headers",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package main
+
+import (
+	// Project
+	"github.com/incu6us/goimports-reviser/pkg"
+
+	// Standard library
+	"fmt"
+	// Third party
+	// for errors
+	"golang.org/x/exp/errors"
+	"strings"
+)
+
+func main() {
+	fmt.Println(strings.ToLower(pkg.A), errors.New)
+}
+`,
+				options: []SourceFileOption{WithGroupHeaders(headers)},
+			},
+			want: `package main
+
+import (
+	// Standard library
+	"fmt"
+	"strings"
+
+	// Third party
+	// for errors
+	"golang.org/x/exp/errors"
+
+	// Project
+	"github.com/incu6us/goimports-reviser/pkg"
+)
+
+func main() {
+	fmt.Println(strings.ToLower(pkg.A), errors.New)
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with named imports in separate group",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package main
+
+import (
+	s "strings"
+	"fmt"
+)
+
+func main() {
+	fmt.Println(s.ToLower("A"))
+}
+`,
+				options: []SourceFileOption{WithGroupHeaders(headers), WithSeparatedNamedImports},
+			},
+			want: `package main
+
+import (
+	// Standard library
+	"fmt"
+
+	s "strings"
+)
+
+func main() {
+	fmt.Println(s.ToLower("A"))
+}
+`,
+			wantChange: true,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).Fix(tt.args.options...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantChange, hasChange)
+			assert.Equal(t, tt.want, string(got))
+
+			// the result should be the same on the next run
+			require.NoError(t, os.WriteFile(tt.args.filePath, got, 0o644))
+			gotAgain, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).Fix(tt.args.options...)
+			require.NoError(t, err)
+
+			assert.False(t, hasChange)
+			assert.Equal(t, string(got), string(gotAgain))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithDirectives(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		options     []SourceFileOption
+	}
+
+	tests := []struct {
+		name            string
+		args            args
+		want            string
+		wantChange      bool
+		wantDiagnostics []string
+	}{
+		{
+			name: "success with skip directive",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `//goimports-reviser:skip
+
+package testdata
+
+import (
+	"strings"
+	"fmt"
+)
+`,
+			},
+			want: `//goimports-reviser:skip
+
+package testdata
+
+import (
+	"strings"
+	"fmt"
+)
+`,
+			wantChange: false,
+		},
+		{
+			name: "success with keep-order directive",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"strings"
+	"fmt"
+)
+
+// init order matters
+//goimports-reviser:keep-order
+import (
+	_ "github.com/incu6us/goimports-reviser/pkg/b"
+	// should be registered after b
+	_ "github.com/incu6us/goimports-reviser/pkg/a"
+)
+
+import "errors"
+
+func main() {
+	fmt.Println(strings.ToLower("A"), errors.New("B"))
+}
+`,
+			},
+			want: `package testdata
+
+// init order matters
+//goimports-reviser:keep-order
+import (
+	_ "github.com/incu6us/goimports-reviser/pkg/b"
+	// should be registered after b
+	_ "github.com/incu6us/goimports-reviser/pkg/a"
+)
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func main() {
+	fmt.Println(strings.ToLower("A"), errors.New("B"))
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with keep-order directive and unformatted imports",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+//goimports-reviser:keep-order
+import (
+	_ "os"   // first
+	_ "errors"
+)
+`,
+			},
+			want: `package testdata
+
+//goimports-reviser:keep-order
+import (
+	_ "os"   // first
+	_ "errors"
+)
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with keep-order directive and adjacent unsorted imports",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+//goimports-reviser:keep-order
+import (
+	_ "os" // first
+	_ "errors"
+	_ "fmt"
+	_ "bytes"
+)
+`,
+			},
+			want: `package testdata
+
+//goimports-reviser:keep-order
+import (
+	_ "os" // first
+	_ "errors"
+	_ "fmt"
+	_ "bytes"
+)
+`,
+			wantChange: false,
+		},
+		{
+			name: "success with group directive",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"fmt"
+	"golang.org/x/exp/slices" //goimports-reviser:group=company
+	"golang.org/x/exp/maps"
+)
+
+func main() {
+	fmt.Println(slices.Contains, maps.Keys)
+}
+`,
+			},
+			want: `package testdata
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/maps"
+
+	"golang.org/x/exp/slices" //goimports-reviser:group=company
+)
+
+func main() {
+	fmt.Println(slices.Contains, maps.Keys)
+}
+`,
+			wantChange: true,
+		},
+		{
+			name: "success with unknown group in directive",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"fmt" //goimports-reviser:group=vendor
+)
+`,
+			},
+			want: `package testdata
+
+import (
+	"fmt" //goimports-reviser:group=vendor
+)
+`,
+			wantChange: false,
+			wantDiagnostics: []string{
+				`./testdata/example.go:4:2: unknown import group "vendor" in directive`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			sourceFile := NewSourceFile(tt.args.projectName, tt.args.filePath)
+			got, _, hasChange, err := sourceFile.Fix(tt.args.options...)
+			require.NoError(t, err)
+
+			var diagnostics []string
+			for _, diagnostic := range sourceFile.Diagnostics() {
+				diagnostics = append(diagnostics, diagnostic.String())
+			}
+
+			assert.Equal(t, tt.wantChange, hasChange)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, tt.wantDiagnostics, diagnostics)
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithPreservedBlankImportsOrder(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		options     []SourceFileOption
+	}
+
+	const fileContent = `package main
+
+import (
+	"fmt"
+	_ "github.com/acme/registry/zookeeper"
+	_ "github.com/lib/pq"
+	_ "github.com/acme/registry/consul"
+	"golang.org/x/exp/slices"
+	_ "github.com/acme/sqltrace"
+	_ "embed"
+)
+
+func main() {
+	fmt.Println(slices.Contains)
+}
+`
+
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "success with all blank imports",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: fileContent,
+				options:     []SourceFileOption{WithPreservedBlankImportsOrder(nil)},
+			},
+			want: `package main
+
+import (
+	_ "embed"
+	"fmt"
+
+	_ "github.com/acme/registry/zookeeper"
+	_ "github.com/lib/pq"
+	"golang.org/x/exp/slices"
+
+	_ "github.com/acme/registry/consul"
+	_ "github.com/acme/sqltrace"
+)
+
+func main() {
+	fmt.Println(slices.Contains)
+}
+`,
+		},
+		{
+			name: "success with paths",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: fileContent,
+				options:     []SourceFileOption{WithPreservedBlankImportsOrder([]string{"github.com/acme/registry"})},
+			},
+			want: `package main
+
+import (
+	_ "embed"
+	"fmt"
+
+	_ "github.com/acme/registry/zookeeper"
+	"golang.org/x/exp/slices"
+
+	_ "github.com/acme/registry/consul"
+	_ "github.com/acme/sqltrace"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	fmt.Println(slices.Contains)
+}
+`,
+		},
+		{
+			name: "success with blanked group",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: fileContent,
+				options: []SourceFileOption{
+					WithPreservedBlankImportsOrder(nil),
+					WithImportsOrder([]ImportsOrder{
+						StdImportsOrder, GeneralImportsOrder, CompanyImportsOrder, ProjectImportsOrder, BlankedImportsOrder,
+					}),
+				},
+			},
+			want: `package main
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/slices"
+
+	_ "github.com/acme/registry/zookeeper"
+	_ "github.com/lib/pq"
+
+	_ "github.com/acme/registry/consul"
+	_ "github.com/acme/sqltrace"
+
+	_ "embed"
+)
+
+func main() {
+	fmt.Println(slices.Contains)
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).Fix(tt.args.options...)
+			require.NoError(t, err)
+
+			assert.True(t, hasChange)
+			assert.Equal(t, tt.want, string(got))
+
+			// the order should be the same on the next run
+			require.NoError(t, os.WriteFile(tt.args.filePath, got, 0o644))
+			gotAgain, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).Fix(tt.args.options...)
+			require.NoError(t, err)
+
+			assert.False(t, hasChange)
+			assert.Equal(t, string(got), string(gotAgain))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithPreservedImportGroups(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		options     []SourceFileOption
+	}
+
+	const fileContent = `package main
+
+import (
+	"golang.org/x/exp/slices"
+	"fmt"
+	"github.com/incu6us/goimports-reviser/pkg"
+
+	// logging
+	"log"
+	"github.com/pkg/errors" // errors
+	"github.com/pkg/errors" // errors
+)
+
+import "strings"
+
+func main() {
+	fmt.Println(slices.Contains, pkg.A, errors.New, strings.ToLower)
+	log.Println()
+}
+`
+
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "success",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: fileContent,
+				options:     []SourceFileOption{WithPreservedImportGroups(false)},
+			},
+			want: `package main
+
+import (
+	"fmt"
+	"github.com/incu6us/goimports-reviser/pkg"
+	"golang.org/x/exp/slices"
+
+	"github.com/pkg/errors" // errors
+	// logging
+	"log"
+
+	"strings"
+)
+
+func main() {
+	fmt.Println(slices.Contains, pkg.A, errors.New, strings.ToLower)
+	log.Println()
+}
+`,
+		},
+		{
+			name: "success with std first",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: fileContent,
+				options:     []SourceFileOption{WithPreservedImportGroups(true)},
+			},
+			want: `package main
+
+import (
+	"fmt"
+	// logging
+	"log"
+	"strings"
+
+	"github.com/incu6us/goimports-reviser/pkg"
+	"golang.org/x/exp/slices"
+
+	"github.com/pkg/errors" // errors
+)
+
+func main() {
+	fmt.Println(slices.Contains, pkg.A, errors.New, strings.ToLower)
+	log.Println()
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).Fix(tt.args.options...)
+			require.NoError(t, err)
+
+			assert.True(t, hasChange)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithBuildContexts(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		contexts    []astutil.BuildContext
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "remove import which is unused for every target",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() {
+	fmt.Println("test")
+}
+`,
+				contexts: astutil.BuildContexts([]string{"linux", "windows"}, []string{"amd64"}, nil),
+			},
+			want: `package testdata
+
+import (
+	"fmt"
+)
+
+func main() {
+	fmt.Println("test")
+}
+`,
+		},
+		{
+			name: "file with custom build tag",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `//go:build tools && !windows
+
+package testdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() {
+	fmt.Println("test")
+}
+`,
+				contexts: astutil.BuildContexts([]string{"linux", "windows"}, []string{"amd64"}, nil),
+			},
+			want: `//go:build tools && !windows
+
+package testdata
+
+import (
+	"fmt"
+)
+
+func main() {
+	fmt.Println("test")
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).Fix(
+				WithRemovingUnusedImports,
+				WithBuildContexts(tt.args.contexts),
+			)
+			require.NoError(t, err)
+
+			assert.True(t, hasChange)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithDirectiveImports(t *testing.T) {
+	type args struct {
+		projectName string
+		filePath    string
+		fileContent string
+		options     []SourceFileOption
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "keep embed and unsafe imports on removing unused imports",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"embed"
+	"strings"
+	"unsafe"
+)
+
+//go:embed example.go
+var content string
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+`,
+				options: []SourceFileOption{WithRemovingUnusedImports},
+			},
+			want: `package testdata
+
+import (
+	"embed"
+	"unsafe"
+)
+
+//go:embed example.go
+var content string
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+`,
+		},
+		{
+			name: "add missing imports",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import "fmt"
+
+//go:embed example.go
+var content string
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+func main() {
+	fmt.Println(content)
+}
+`,
+				options: []SourceFileOption{WithAddingMissingImports},
+			},
+			want: `package testdata
+
+import (
+	_ "embed" // required by //go:embed
+	"fmt"
+	_ "unsafe" // required by //go:linkname
+)
+
+//go:embed example.go
+var content string
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+func main() {
+	fmt.Println(content)
+}
+`,
+		},
+		{
+			name: "add missing import into import block",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+import (
+	"fmt"
+	"os"
+)
+// nanotime is linked
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+func main() {
+	fmt.Println(os.Args)
+}
+`,
+				options: []SourceFileOption{WithAddingMissingImports},
+			},
+			want: `package testdata
+
+import (
+	"fmt"
+	"os"
+	_ "unsafe" // required by //go:linkname
+)
+
+// nanotime is linked
+//
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+func main() {
+	fmt.Println(os.Args)
+}
+`,
+		},
+		{
+			name: "add missing imports without import declaration",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+//go:embed example.go
+var content string
+`,
+				options: []SourceFileOption{WithAddingMissingImports},
+			},
+			want: `package testdata
+
+import (
+	_ "embed" // required by //go:embed
+)
+
+//go:embed example.go
+var content string
+`,
+		},
+		{
+			name: "add missing import with cgo import only",
+			args: args{
+				projectName: "github.com/incu6us/goimports-reviser",
+				filePath:    "./testdata/example.go",
+				fileContent: `package testdata
+
+// #include <stdlib.h>
+import "C"
+
+//go:embed example.go
+var content string
+`,
+				options: []SourceFileOption{WithAddingMissingImports},
+			},
+			want: `package testdata
+
+import (
+	_ "embed" // required by //go:embed
+)
+
+// #include <stdlib.h>
+import "C"
+
+//go:embed example.go
+var content string
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(tt.args.filePath, []byte(tt.args.fileContent), 0o644))
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, hasChange, err := NewSourceFile(tt.args.projectName, tt.args.filePath).Fix(tt.args.options...)
+			require.NoError(t, err)
+
+			assert.True(t, hasChange)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithModernization(t *testing.T) {
+	const newXExp = "golang.org/x/exp v0.0.0-20250210185358-939b2ce775ac"
+
+	tests := []struct {
+		name        string
+		goMod       string
+		fileContent string
+		want        string
+		wantChange  bool
+	}{
+		{
+			name:  "migrate x/exp and ioutil",
+			goMod: "go 1.21\n\nrequire " + newXExp + "\n",
+			fileContent: `package testproject
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+func main() {
+	data, _ := ioutil.ReadAll(strings.NewReader("a"))
+	_ = ioutil.WriteFile("a", data, 0o644)
+	entries, _ := ioutil.ReadDir(".")
+
+	m := map[string]int{"a": 1}
+	m2 := maps.Clone(m)
+	maps.Clear(m)
+
+	s := []int{2, 1}
+	slices.SortFunc(s, func(a, b int) int { return a - b })
+	fmt.Println(entries, m2, slices.Contains(s, 1))
+}
+`,
+			want: `package testproject
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+func main() {
+	data, _ := io.ReadAll(strings.NewReader("a"))
+	_ = os.WriteFile("a", data, 0o644)
+	entries, _ := ioutil.ReadDir(".")
+
+	m := map[string]int{"a": 1}
+	m2 := maps.Clone(m)
+	clear(m)
+
+	s := []int{2, 1}
+	slices.SortFunc(s, func(a, b int) int { return a - b })
+	fmt.Println(entries, m2, slices.Contains(s, 1))
+}
+`,
+			wantChange: true,
+		},
+		{
+			name:  "keep x/exp/maps if builtin replacement is not called",
+			goMod: "go 1.21\n\nrequire " + newXExp + "\n",
+			fileContent: `package testproject
+
+import (
+	"golang.org/x/exp/maps"
+)
+
+var clearFn = maps.Clear[map[string]int]
+
+func main() {
+	m := map[string]int{"a": 1}
+	maps.Clear(m)
+	clearFn(m)
+}
+`,
+			want: `package testproject
+
+import (
+	"golang.org/x/exp/maps"
+)
+
+var clearFn = maps.Clear[map[string]int]
+
+func main() {
+	m := map[string]int{"a": 1}
+	maps.Clear(m)
+	clearFn(m)
+}
+`,
+		},
+		{
+			name:  "keep x/exp for go version before 1.21",
+			goMod: "go 1.20\n\nrequire " + newXExp + "\n",
+			fileContent: `package testproject
+
+import (
+	"io/ioutil"
+
+	"golang.org/x/exp/slices"
+)
+
+func main() {
+	data, _ := ioutil.ReadFile("a")
+	_ = slices.Contains(data, 'a')
+}
+`,
+			want: `package testproject
+
+import (
+	"os"
+
+	"golang.org/x/exp/slices"
+)
+
+func main() {
+	data, _ := os.ReadFile("a")
+	_ = slices.Contains(data, 'a')
+}
+`,
+			wantChange: true,
+		},
+		{
+			name:  "keep x/exp with incompatible uses",
+			goMod: "go 1.22\n\nrequire golang.org/x/exp v0.0.0-20230522175609-2e198f4a06a1\n",
+			fileContent: `package testproject
+
+import (
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+func main() {
+	m := map[int]bool{}
+	s := maps.Keys(m)
+	slices.SortFunc(s, func(a, b int) bool { return a < b })
+}
+`,
+			want: `package testproject
+
+import (
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+func main() {
+	m := map[int]bool{}
+	s := maps.Keys(m)
+	slices.SortFunc(s, func(a, b int) bool { return a < b })
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			require.NoError(t, os.WriteFile(
+				filepath.Join(tmpDir, "go.mod"),
+				[]byte("module example.com/testproject\n\n"+tt.goMod),
+				0o644,
+			))
+
+			filePath := filepath.Join(tmpDir, "main.go")
+			require.NoError(t, os.WriteFile(filePath, []byte(tt.fileContent), 0o644))
+
+			got, _, hasChange, err := NewSourceFile("example.com/testproject", filePath).Fix(WithModernization)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantChange, hasChange)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSourceFile_Fix_DiagnosticPositionsAfterRewrite(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileContent string
+		option      SourceFileOption
+	}{
+		{
+			name: "add missing imports",
+			fileContent: `package testproject
+
+import "fmt"
+
+import _ "net/http/pprof"
+
+//go:embed main.go
+var content string
+
+func main() { fmt.Println(content) }
+`,
+			option: WithAddingMissingImports,
+		},
+		{
+			name: "modernize imports",
+			fileContent: `package testproject
+
+import "io/ioutil"
+
+import _ "net/http/pprof"
+
+func main() {
+	_, _ = ioutil.ReadFile("a")
+	_, _ = ioutil.ReadDir(".")
+}
+`,
+			option: WithModernization,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			require.NoError(t, os.WriteFile(
+				filepath.Join(tmpDir, "go.mod"),
+				[]byte("module example.com/testproject\n\ngo 1.21\n"),
+				0o644,
+			))
+
+			filePath := filepath.Join(tmpDir, "main.go")
+			require.NoError(t, os.WriteFile(filePath, []byte(tt.fileContent), 0o644))
+
+			sourceFile := NewSourceFile("example.com/testproject", filePath)
+			_, _, hasChange, err := sourceFile.Fix(tt.option, WithImportHygieneRules(nil))
+			require.NoError(t, err)
+			assert.True(t, hasChange)
+
+			diagnostics := sourceFile.Diagnostics()
+			require.Len(t, diagnostics, 1)
+			assert.Equal(t, blankImportCategory, diagnostics[0].Category)
+			assert.Equal(t, 5, diagnostics[0].Pos.Line)
+			assert.Equal(t, 8, diagnostics[0].Pos.Column)
+			assert.Equal(t, strings.Index(tt.fileContent, `_ "net/http/pprof"`), diagnostics[0].Pos.Offset)
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithSourceContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(
+		filepath.Join(tmpDir, "go.mod"),
+		[]byte("module example.com/testproject\n\ngo 1.21\n"),
+		0o644,
+	))
+
+	const workTreeContent = `package testproject
+
+import "fmt"
+
+func main() { fmt.Println() }
+`
+	filePath := filepath.Join(tmpDir, "main.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(workTreeContent), 0o644))
+
+	got, _, hasChange, err := NewSourceFile("example.com/testproject", filePath).Fix(
+		WithSourceContent([]byte(`package testproject
+
+import (
+	"os"
+	"fmt"
+	"strings"
+)
+
+func main() { fmt.Println(os.Args) }
+`)),
+		WithRemovingUnusedImports,
+	)
+	require.NoError(t, err)
+
+	assert.True(t, hasChange)
+	assert.Equal(t, `package testproject
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() { fmt.Println(os.Args) }
+`, string(got))
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, workTreeContent, string(content), "the file must not be read or written")
+}
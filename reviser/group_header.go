@@ -0,0 +1,121 @@
+package reviser
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+const commentPrefix = "//"
+
+// StringToGroupHeaders will convert string, like "std=Standard library,general=Third party" to header comments of the
+// import groups. Allowed groups: std, general, company, project, blanked, dotted.
+func StringToGroupHeaders(s string) (map[ImportsOrder]string, error) {
+	headers := map[ImportsOrder]string{}
+	for _, pair := range strings.Split(s, stringValueSeparator) {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		values := strings.SplitN(pair, aliasValueSeparator, 2)
+		if len(values) != 2 || strings.TrimSpace(values[1]) == "" {
+			return nil, fmt.Errorf(`invalid group header %q, expected format is "group=header"`, pair)
+		}
+
+		group := ImportsOrder(strings.TrimSpace(values[0]))
+		switch group {
+		case StdImportsOrder, CompanyImportsOrder, ProjectImportsOrder,
+			GeneralImportsOrder, BlankedImportsOrder, DottedImportsOrder:
+		default:
+			return nil, fmt.Errorf(`unknown order group type: %q`, group)
+		}
+
+		headers[group] = headerComment(values[1])
+	}
+
+	return headers, nil
+}
+
+// headerComment returns the text as a line comment: "Third party" => "// Third party"
+func headerComment(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, commentPrefix) {
+		return text
+	}
+
+	return commentPrefix + " " + text
+}
+
+// groupHeaderComments returns the generated header for every group of imports returned by sortImportsByOrder
+func (f *SourceFile) groupHeaderComments() []string {
+	if len(f.groupHeaders) == 0 {
+		return nil
+	}
+
+	groups := f.importsOrders
+	if len(groups) == 0 {
+		groups = ImportsOrders{
+			StdImportsOrder, NamedStdImportsOrder,
+			GeneralImportsOrder, NamedGeneralImportsOrder,
+			CompanyImportsOrder, NamedCompanyImportsOrder,
+			ProjectImportsOrder, NamedProjectImportsOrder,
+		}
+	}
+
+	headers := make([]string, 0, len(groups))
+	for _, group := range groups {
+		headers = append(headers, f.groupHeaders[unnamedImportsOrder(group)])
+	}
+
+	return headers
+}
+
+func unnamedImportsOrder(group ImportsOrder) ImportsOrder {
+	switch group {
+	case NamedStdImportsOrder:
+		return StdImportsOrder
+	case NamedGeneralImportsOrder:
+		return GeneralImportsOrder
+	case NamedCompanyImportsOrder:
+		return CompanyImportsOrder
+	case NamedProjectImportsOrder:
+		return ProjectImportsOrder
+	}
+
+	return group
+}
+
+// stripGroupHeaders removes comments which are equal to one of the generated headers, so generated headers are not
+// duplicated on the next run
+func (f *SourceFile) stripGroupHeaders(commentGroup *ast.CommentGroup) *ast.CommentGroup {
+	if commentGroup == nil || len(f.groupHeaders) == 0 {
+		return commentGroup
+	}
+
+	var comments []*ast.Comment
+	for _, c := range commentGroup.List {
+		if !f.isGroupHeader(c.Text) {
+			comments = append(comments, c)
+		}
+	}
+
+	if len(comments) == 0 {
+		return nil
+	}
+	if len(comments) == len(commentGroup.List) {
+		return commentGroup
+	}
+
+	return &ast.CommentGroup{List: comments}
+}
+
+func (f *SourceFile) isGroupHeader(text string) bool {
+	for _, header := range f.groupHeaders {
+		if strings.TrimSpace(text) == header {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,58 @@
+package reviser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringToGroupHeaders(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		headers string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    map[ImportsOrder]string
+		wantErr string
+	}{
+		{
+			name: "success",
+			args: args{headers: "std=Standard library, general = Third party,company=// Acme=Corp,"},
+			want: map[ImportsOrder]string{
+				StdImportsOrder:     "// Standard library",
+				GeneralImportsOrder: "// Third party",
+				CompanyImportsOrder: "// Acme=Corp",
+			},
+		},
+		{
+			name:    "missing header",
+			args:    args{headers: "std="},
+			wantErr: `invalid group header "std=", expected format is "group=header"`,
+		},
+		{
+			name:    "unknown group",
+			args:    args{headers: "vendor=Vendor"},
+			wantErr: `unknown order group type: "vendor"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := StringToGroupHeaders(tt.args.headers)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package reviser
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	blankImportCategory   = "blank-import"
+	testingImportCategory = "testing-import"
+
+	testFileSuffix = "_test.go"
+)
+
+// defaultTestingPackages is a list of packages which should be imported by test files only
+var defaultTestingPackages = []string{
+	"testing",
+	"github.com/stretchr/testify",
+	"github.com/golang/mock/gomock",
+	"go.uber.org/mock/gomock",
+}
+
+// checkImportHygiene reports blank imports without a trailing comment justifying them outside of main and test
+// packages, and testing-only packages which are imported from non-test files. All imports of the file are checked,
+// including imports of declarations which are not sorted(ex.: with keep-order directive).
+func (f *SourceFile) checkImportHygiene(fset *token.FileSet, file *ast.File) {
+	if !f.shouldCheckImportHygiene {
+		return
+	}
+
+	isTestFile := strings.HasSuffix(f.filePath, testFileSuffix)
+	isMainPackage := file.Name.Name == "main"
+
+	for _, importSpec := range file.Imports {
+		pkg := strings.Trim(importSpec.Path.Value, `"`)
+		isBlank := importSpec.Name != nil && importSpec.Name.Name == "_"
+
+		if isBlank && !isMainPackage && !isTestFile && importSpec.Comment == nil {
+			f.report(fset, importSpec.Pos(), blankImportCategory, "blank import %q should have a comment justifying it", pkg)
+		}
+
+		if !isTestFile && isPathInPackages(pkg, f.testingPackages) {
+			f.report(
+				fset, importSpec.Pos(), testingImportCategory,
+				"testing package %q should not be imported from non-test file %s", pkg, filepath.Base(f.filePath),
+			)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package reviser
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/module"
+	"github.com/incu6us/goimports-reviser/v3/pkg/std"
+)
+
+const majorVersionCategory = "major-version"
+
+// checkMajorVersions reports imports which major version doesn't match the module required by go.mod(ex.:
+// "github.com/foo/bar" while go.mod requires "github.com/foo/bar/v3"), and files which import different major versions
+// of the same module. With shouldFixMajorVersions imports are rewritten to the required major version, only imports
+// which can't be fixed are reported.
+func (f *SourceFile) checkMajorVersions(fset *token.FileSet, file *ast.File) {
+	if !f.shouldCheckMajorVersions && !f.shouldFixMajorVersions {
+		return
+	}
+
+	root, err := module.GoModRootPath(filepath.Dir(f.filePath))
+	if err != nil || root == "" {
+		return
+	}
+
+	modulePaths, err := module.RequiredModules(root)
+	if err != nil {
+		return
+	}
+	if name, err := module.Name(root); err == nil {
+		modulePaths = append(modulePaths, name)
+	}
+	majorVersions := module.MajorVersions(modulePaths)
+
+	// path majors of the imports by the module, to find files which mix major versions
+	importedMajors := map[string][]string{}
+	for _, importSpec := range file.Imports {
+		importPath := strings.Trim(importSpec.Path.Value, `"`)
+		if _, ok := std.StdPackages[importPath]; ok || importPath == "C" {
+			continue
+		}
+
+		importModule, ok := module.SplitImportPath(majorVersions, importPath)
+		if !ok {
+			continue
+		}
+
+		requiredMajors := majorVersions[importModule.PathPrefix]
+		switch {
+		case slices.Contains(requiredMajors, importModule.PathMajor):
+			// matches go.mod
+		case len(requiredMajors) > 1:
+			f.report(
+				fset, importSpec.Pos(), majorVersionCategory,
+				"import %q doesn't match any of major versions of module %q required by go.mod: %s",
+				importPath, importModule.PathPrefix, strings.Join(majorVersionNames(requiredMajors), ", "),
+			)
+		case f.shouldFixMajorVersions:
+			importModule.PathMajor = requiredMajors[0]
+			importSpec.Path.Value = strconv.Quote(importModule.PathPrefix + importModule.PathMajor + importModule.Subpath)
+		default:
+			f.report(
+				fset, importSpec.Pos(), majorVersionCategory,
+				"import %q doesn't match the major version of module %q required by go.mod",
+				importPath, importModule.PathPrefix+requiredMajors[0],
+			)
+		}
+
+		majors := importedMajors[importModule.PathPrefix]
+		if slices.Contains(majors, importModule.PathMajor) {
+			continue
+		}
+
+		majors = append(majors, importModule.PathMajor)
+		importedMajors[importModule.PathPrefix] = majors
+		if len(majors) > 1 {
+			f.report(
+				fset, importSpec.Pos(), majorVersionCategory,
+				"imports mix major versions of module %q: %s",
+				importModule.PathPrefix, strings.Join(majorVersionNames(majors), ", "),
+			)
+		}
+	}
+}
+
+// majorVersionNames converts major version suffixes of module paths to version names(ex.: "/v3" => v3, "" => v0/v1)
+func majorVersionNames(pathMajors []string) []string {
+	names := make([]string, 0, len(pathMajors))
+	for _, pathMajor := range pathMajors {
+		if pathMajor == "" {
+			names = append(names, "v0/v1")
+		} else {
+			names = append(names, strings.TrimLeft(pathMajor, "/."))
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,71 @@
+package reviser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+)
+
+// directiveImportComments are comments of the added imports which name the directive that requires the import
+var directiveImportComments = map[string]string{
+	"embed":  "// required by //go:embed",
+	"unsafe": "// required by //go:linkname",
+}
+
+// addMissingImports returns the content with blank imports which are required by compiler directives, but are not
+// imported: `_ "embed"` for `//go:embed` and `_ "unsafe"` for `//go:linkname`. Imports are added to the source instead of
+// the AST, so comments which follow the import declaration keep their positions. Added imports get a comment with the
+// directive, which justifies the blank import.
+func (f *SourceFile) addMissingImports(fset *token.FileSet, file *ast.File, content []byte) []byte {
+	if !f.shouldAddMissingImports {
+		return content
+	}
+
+	imported := make(map[string]struct{}, len(file.Imports))
+	for _, importSpec := range file.Imports {
+		imported[strings.Trim(importSpec.Path.Value, `"`)] = struct{}{}
+	}
+
+	var specs bytes.Buffer
+	for _, importPath := range astutil.DirectiveImports(file) {
+		if _, ok := imported[importPath]; !ok {
+			fmt.Fprintf(&specs, "_ %q %s\n", importPath, directiveImportComments[importPath])
+		}
+	}
+	if specs.Len() == 0 {
+		return content
+	}
+
+	var importDecl *ast.GenDecl
+	for _, decl := range file.Decls {
+		if dd, ok := decl.(*ast.GenDecl); ok && dd.Tok == token.IMPORT && !isSeparateImportDecl(dd) {
+			importDecl = dd
+			break
+		}
+	}
+
+	tokFile := fset.File(file.Package)
+	switch {
+	case importDecl == nil:
+		offset := tokFile.Offset(file.Name.End())
+		return insertContent(content, offset, offset, "\n\nimport (\n"+specs.String()+")")
+	case importDecl.Lparen.IsValid():
+		offset := tokFile.Offset(importDecl.Rparen)
+		return insertContent(content, offset, offset, "\n"+specs.String())
+	default:
+		start, end := tokFile.Offset(importDecl.Specs[0].Pos()), tokFile.Offset(importDecl.End())
+		return insertContent(content, start, end, "(\n"+string(content[start:end])+"\n"+specs.String()+")")
+	}
+}
+
+// insertContent replaces content[start:end] with s
+func insertContent(content []byte, start, end int, s string) []byte {
+	result := make([]byte, 0, len(content)+len(s))
+	result = append(result, content[:start]...)
+	result = append(result, s...)
+	return append(result, content[end:]...)
+}
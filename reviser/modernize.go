@@ -0,0 +1,337 @@
+package reviser
+
+import (
+	"go/ast"
+	"go/token"
+	"go/version"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	xmodule "golang.org/x/mod/module"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+	"github.com/incu6us/goimports-reviser/v3/pkg/module"
+)
+
+const (
+	go116 = "go1.16"
+	go121 = "go1.21"
+	go122 = "go1.22"
+
+	xExpModulePath = "golang.org/x/exp"
+)
+
+// xExpCmpFuncsTime is the time of golang.org/x/exp version where functions of slices package with `less` callback
+// (SortFunc, BinarySearchFunc, etc.) got `cmp` callback like in std
+var xExpCmpFuncsTime = time.Date(2023, time.July, 13, 0, 0, 0, 0, time.UTC)
+
+// modernizeTarget is the replacement of the selector: the package and the name in it. Builtin function is used if path
+// is empty(ex.: `clear`).
+type modernizeTarget struct {
+	path      string
+	name      string
+	goVersion string
+}
+
+// modernizeRule migrates uses of the package to std. Selectors which are missing in funcs have no compatible
+// replacement, the import of the package is kept if the file uses any of them.
+type modernizeRule struct {
+	path      string
+	goVersion string
+	funcs     map[string]modernizeTarget
+	// xExpCmpFuncs are functions which are compatible only with golang.org/x/exp versions since xExpCmpFuncsTime
+	xExpCmpFuncs map[string]struct{}
+}
+
+var modernizeRules = []modernizeRule{
+	{
+		path:      "golang.org/x/exp/slices",
+		goVersion: go121,
+		funcs: stdFuncs("slices", go121,
+			"BinarySearch", "BinarySearchFunc", "Clip", "Clone", "Compact", "CompactFunc", "Compare", "CompareFunc",
+			"Contains", "ContainsFunc", "Delete", "DeleteFunc", "Equal", "EqualFunc", "Grow", "Index", "IndexFunc",
+			"Insert", "IsSorted", "IsSortedFunc", "Max", "MaxFunc", "Min", "MinFunc", "Replace", "Reverse", "Sort",
+			"SortFunc", "SortStableFunc",
+		).with(stdFuncs("slices", go122, "Concat")),
+		xExpCmpFuncs: map[string]struct{}{
+			"BinarySearchFunc": {}, "IsSortedFunc": {}, "MaxFunc": {}, "MinFunc": {}, "SortFunc": {}, "SortStableFunc": {},
+		},
+	},
+	{
+		path:      "golang.org/x/exp/maps",
+		goVersion: go121,
+		funcs: stdFuncs("maps", go121, "Clone", "Copy", "DeleteFunc", "Equal", "EqualFunc").
+			with(modernizeFuncs{"Clear": {name: "clear", goVersion: go121}}),
+	},
+	{
+		path:      "io/ioutil",
+		goVersion: go116,
+		funcs: modernizeFuncs{
+			"Discard":   {path: "io", name: "Discard", goVersion: go116},
+			"NopCloser": {path: "io", name: "NopCloser", goVersion: go116},
+			"ReadAll":   {path: "io", name: "ReadAll", goVersion: go116},
+			"ReadFile":  {path: "os", name: "ReadFile", goVersion: go116},
+			"WriteFile": {path: "os", name: "WriteFile", goVersion: go116},
+			"TempFile":  {path: "os", name: "CreateTemp", goVersion: go116},
+			"TempDir":   {path: "os", name: "MkdirTemp", goVersion: go116},
+		},
+	},
+}
+
+type modernizeFuncs map[string]modernizeTarget
+
+func stdFuncs(pkg, goVersion string, names ...string) modernizeFuncs {
+	funcs := make(modernizeFuncs, len(names))
+	for _, name := range names {
+		funcs[name] = modernizeTarget{path: pkg, name: name, goVersion: goVersion}
+	}
+
+	return funcs
+}
+
+func (m modernizeFuncs) with(funcs modernizeFuncs) modernizeFuncs {
+	for name, target := range funcs {
+		m[name] = target
+	}
+
+	return m
+}
+
+// modernizeImports migrates imports of golang.org/x/exp/slices, golang.org/x/exp/maps and io/ioutil to std packages
+// which are available for the Go version of go.mod, and rewrites uses with compatible signatures. Returns true if the
+// file was changed.
+func (f *SourceFile) modernizeImports(file *ast.File) bool {
+	if !f.shouldModernize {
+		return false
+	}
+
+	root, err := module.GoModRootPath(filepath.Dir(f.filePath))
+	if err != nil || root == "" {
+		return false
+	}
+
+	goVersion, err := module.GoVersion(root)
+	if err != nil || goVersion == "" {
+		return false
+	}
+	goVersion = "go" + goVersion
+
+	xExpVersion, _ := module.RequiredVersion(root, xExpModulePath)
+
+	var changed bool
+	for _, rule := range modernizeRules {
+		if version.Compare(goVersion, rule.goVersion) < 0 {
+			continue
+		}
+
+		for _, importSpec := range append([]*ast.ImportSpec{}, file.Imports...) {
+			if strings.Trim(importSpec.Path.Value, `"`) == rule.path {
+				changed = rule.apply(file, importSpec, goVersion, xExpVersion) || changed
+			}
+		}
+	}
+
+	return changed
+}
+
+func (r modernizeRule) apply(file *ast.File, importSpec *ast.ImportSpec, goVersion, xExpVersion string) bool {
+	name := path.Base(r.path)
+	if importSpec.Name != nil {
+		name = importSpec.Name.Name
+	}
+	if name == "_" || name == "." {
+		return false
+	}
+
+	// builtin functions can only be called, so other uses(ex.: a func value or an instantiation) keep the import
+	calls := calledSelectors(file)
+
+	targets := map[string]modernizeTarget{}
+	var hasIncompatibleUses bool
+	astutil.RewriteImportUses(file, name, func(sel *ast.SelectorExpr) ast.Expr {
+		target, ok := r.target(file, sel.Sel.Name, goVersion, xExpVersion)
+		if _, isCall := calls[sel]; ok && (target.path != "" || isCall) {
+			targets[sel.Sel.Name] = target
+		} else {
+			hasIncompatibleUses = true
+		}
+		return nil
+	})
+	if len(targets) == 0 {
+		return false
+	}
+
+	if r.isSamePackageName() {
+		// the old package and its replacement can't be imported together, so the import is migrated only if all uses
+		// are compatible
+		if hasIncompatibleUses || importedName(file, path.Base(r.path)) != "" {
+			return false
+		}
+		importSpec.Path.Value = strconv.Quote(path.Base(r.path))
+	}
+
+	targetNames := map[string]string{}
+	for _, target := range targets {
+		if _, ok := targetNames[target.path]; ok || target.path == "" || target.path == path.Base(r.path) {
+			continue
+		}
+
+		targetName := importedName(file, target.path)
+		if targetName == "" {
+			targetName = path.Base(target.path)
+			if hasImportName(file, nil, targetName) || astutil.IsNameDeclared(file, targetName) {
+				continue
+			}
+			addImportSpec(file, importSpec, target.path)
+		}
+		targetNames[target.path] = targetName
+	}
+
+	var hasOldUses bool
+	astutil.RewriteImportUses(file, name, func(sel *ast.SelectorExpr) ast.Expr {
+		target, ok := targets[sel.Sel.Name]
+		_, isCall := calls[sel]
+		switch {
+		case ok && target.path == "" && isCall:
+			return &ast.Ident{NamePos: sel.Pos(), Name: target.name}
+		case ok && target.path != "" && targetNames[target.path] != "":
+			return &ast.SelectorExpr{
+				X:   &ast.Ident{NamePos: sel.Pos(), Name: targetNames[target.path]},
+				Sel: &ast.Ident{NamePos: sel.Sel.Pos(), Name: target.name},
+			}
+		}
+
+		hasOldUses = true
+		return nil
+	})
+
+	if !hasOldUses {
+		removeImportSpec(file, importSpec)
+	}
+
+	return true
+}
+
+// isSamePackageName checks if the package is replaced with std package of the same name(ex.: "golang.org/x/exp/slices"
+// with "slices")
+func (r modernizeRule) isSamePackageName() bool {
+	for _, target := range r.funcs {
+		if target.path == path.Base(r.path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// target returns the compatible replacement of the selector
+func (r modernizeRule) target(file *ast.File, name, goVersion, xExpVersion string) (modernizeTarget, bool) {
+	target, ok := r.funcs[name]
+	if !ok || version.Compare(goVersion, target.goVersion) < 0 {
+		return modernizeTarget{}, false
+	}
+
+	if target.path == "" && astutil.IsNameDeclared(file, target.name) {
+		return modernizeTarget{}, false
+	}
+
+	if _, ok := r.xExpCmpFuncs[name]; ok {
+		t, err := xmodule.PseudoVersionTime(xExpVersion)
+		if err != nil || t.Before(xExpCmpFuncsTime) {
+			return modernizeTarget{}, false
+		}
+	}
+
+	return target, true
+}
+
+// calledSelectors returns selectors which are called directly, like `pkg.Func(...)`
+func calledSelectors(file *ast.File) map[*ast.SelectorExpr]struct{} {
+	calls := map[*ast.SelectorExpr]struct{}{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		if call, ok := node.(*ast.CallExpr); ok {
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+				calls[sel] = struct{}{}
+			}
+		}
+		return true
+	})
+
+	return calls
+}
+
+// importedName returns the name of the package which is imported by the file, or empty string if it's not imported
+func importedName(file *ast.File, importPath string) string {
+	for _, importSpec := range file.Imports {
+		if strings.Trim(importSpec.Path.Value, `"`) != importPath {
+			continue
+		}
+
+		if importSpec.Name == nil {
+			return path.Base(importPath)
+		}
+		if importSpec.Name.Name != "_" && importSpec.Name.Name != "." {
+			return importSpec.Name.Name
+		}
+	}
+
+	return ""
+}
+
+// addImportSpec adds the import next to the import spec. The new import spec gets the position of the existing one, so
+// the printer keeps comments which follow the import declaration in place.
+func addImportSpec(file *ast.File, next *ast.ImportSpec, importPath string) {
+	importSpec := &ast.ImportSpec{
+		Path: &ast.BasicLit{ValuePos: next.Path.Pos(), Kind: token.STRING, Value: strconv.Quote(importPath)},
+	}
+
+	for _, decl := range file.Decls {
+		dd, ok := decl.(*ast.GenDecl)
+		if !ok || dd.Tok != token.IMPORT {
+			continue
+		}
+
+		for _, spec := range dd.Specs {
+			if spec == next {
+				dd.Specs = append(dd.Specs, importSpec)
+				file.Imports = append(file.Imports, importSpec)
+				return
+			}
+		}
+	}
+}
+
+// removeImportSpec removes the import from its declaration and drops the declaration if it has no imports left
+func removeImportSpec(file *ast.File, importSpec *ast.ImportSpec) {
+	decls := make([]ast.Decl, 0, len(file.Decls))
+	for _, decl := range file.Decls {
+		dd, ok := decl.(*ast.GenDecl)
+		if !ok || dd.Tok != token.IMPORT {
+			decls = append(decls, decl)
+			continue
+		}
+
+		specs := make([]ast.Spec, 0, len(dd.Specs))
+		for _, spec := range dd.Specs {
+			if spec != importSpec {
+				specs = append(specs, spec)
+			}
+		}
+		dd.Specs = specs
+		if len(specs) > 0 {
+			decls = append(decls, dd)
+		}
+	}
+	file.Decls = decls
+
+	imports := make([]*ast.ImportSpec, 0, len(file.Imports))
+	for _, spec := range file.Imports {
+		if spec != importSpec {
+			imports = append(imports, spec)
+		}
+	}
+	file.Imports = imports
+}
@@ -0,0 +1,114 @@
+package reviser
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+	"github.com/incu6us/goimports-reviser/v3/pkg/module"
+)
+
+// preloadBatchSize limits the number of package directories which are loaded with a single `go list` call
+const preloadBatchSize = 256
+
+// PackageImportsCache shares names of the imported packages between files of the same package directory, so `go list`
+// runs once per directory and build configuration instead of once per file. It's safe for concurrent use.
+type PackageImportsCache struct {
+	mu      sync.Mutex
+	entries map[packageImportsKey]*packageImportsEntry
+}
+
+type packageImportsKey struct {
+	dir string
+	ctx string
+}
+
+type packageImportsEntry struct {
+	once    sync.Once
+	imports astutil.PackageImports
+	err     error
+}
+
+// NewPackageImportsCache constructor
+func NewPackageImportsCache() *PackageImportsCache {
+	return &PackageImportsCache{
+		entries: map[packageImportsKey]*packageImportsEntry{},
+	}
+}
+
+// Load returns names of the packages imported by the package in dir for the build configuration ctx(see
+// astutil.LoadPackageDependenciesForContext). The package is loaded only on the first call, the result must not be
+// modified.
+func (c *PackageImportsCache) Load(dir string, ctx astutil.BuildContext) (astutil.PackageImports, error) {
+	entry := c.entry(dir, ctx)
+	entry.once.Do(func() {
+		entry.imports, entry.err = astutil.LoadPackageDependenciesForContext(dir, ctx)
+	})
+
+	return entry.imports, entry.err
+}
+
+// Preload loads packages of dirs for the build configuration ctx with a single `go list` call per module(and per
+// batch of directories). Directories which fail to be loaded this way are loaded separately by Load.
+func (c *PackageImportsCache) Preload(dirs []string, ctx astutil.BuildContext) {
+	moduleDirs := map[string][]string{}
+	for _, dir := range dirs {
+		root, err := module.GoModRootPath(dir)
+		if err != nil || root == "" {
+			continue
+		}
+		moduleDirs[root] = append(moduleDirs[root], dir)
+	}
+
+	roots := make([]string, 0, len(moduleDirs))
+	for root := range moduleDirs {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+
+	for _, root := range roots {
+		dirs := moduleDirs[root]
+		for start := 0; start < len(dirs); start += preloadBatchSize {
+			batch := dirs[start:min(start+preloadBatchSize, len(dirs))]
+
+			result, err := astutil.LoadPackagesDependencies(root, batch, ctx)
+			if err != nil {
+				continue
+			}
+
+			for _, dir := range batch {
+				dependencies, ok := result[dir]
+				if !ok {
+					continue
+				}
+
+				var loadErr *astutil.PackageLoadError
+				if dependencies.Err != nil && !errors.As(dependencies.Err, &loadErr) {
+					continue
+				}
+
+				entry := c.entry(dir, ctx)
+				entry.once.Do(func() {
+					entry.imports, entry.err = dependencies.Imports, dependencies.Err
+				})
+			}
+		}
+	}
+}
+
+func (c *PackageImportsCache) entry(dir string, ctx astutil.BuildContext) *packageImportsEntry {
+	key := packageImportsKey{dir: filepath.Clean(dir), ctx: ctx.String()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &packageImportsEntry{}
+		c.entries[key] = entry
+	}
+
+	return entry
+}
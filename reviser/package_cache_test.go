@@ -0,0 +1,71 @@
+package reviser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+)
+
+func TestPackageImportsCache(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":           "module example.com/project\n\ngo 1.21\n",
+		"a/a.go":           "package a\n\nimport \"strings\"\n\nvar _ = strings.ToLower\n",
+		"b/b.go":           "package bee\n\nimport \"example.com/project/a\"\n\nvar _ = a.A\n",
+		"a/a_const.go":     "package a\n\nconst A = 1\n",
+		"broken/broken.go": "package broken\n\nimport \"example.com/project/missing\"\n\nvar _ = missing.M\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o644))
+	}
+
+	ctx := astutil.DefaultBuildContext()
+	cache := NewPackageImportsCache()
+	cache.Preload([]string{filepath.Join(root, "a"), filepath.Join(root, "b"), filepath.Join(root, "broken")}, ctx)
+
+	// preloaded results are used, the package is not loaded again
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a", "a.go"), []byte("package a\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint\n"), 0o644))
+
+	got, err := cache.Load(filepath.Join(root, "a"), ctx)
+	require.NoError(t, err)
+	assert.Equal(t, astutil.PackageImports{"strings": "strings"}, got)
+
+	got, err = cache.Load(filepath.Join(root, "b"), ctx)
+	require.NoError(t, err)
+	assert.Equal(t, astutil.PackageImports{"example.com/project/a": "a"}, got)
+
+	_, err = cache.Load(filepath.Join(root, "broken"), ctx)
+	var loadErr *astutil.PackageLoadError
+	assert.ErrorAs(t, err, &loadErr)
+
+	// directories which were not preloaded are loaded on the first call
+	got, err = NewPackageImportsCache().Load(filepath.Join(root, "a"), ctx)
+	require.NoError(t, err)
+	assert.Equal(t, astutil.PackageImports{"fmt": "fmt"}, got)
+}
+
+func TestSourceDir_Fix_WithPackageImportsCache(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/project\n\ngo 1.21\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "v2"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "v2", "v2.go"), []byte("package pkg\n\nfunc F() {}\n"), 0o644))
+
+	content := "package project\n\nimport (\n\t\"example.com/project/v2\"\n)\n\nvar _ = pkg.F\n"
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o644))
+	}
+
+	require.NoError(t, NewSourceDir("example.com/project", root, false, "", WithWorkers(2)).Fix(WithUsingAliasForVersionSuffix))
+
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		got, err := os.ReadFile(filepath.Join(root, name))
+		require.NoError(t, err)
+		assert.Equal(t, "package project\n\nimport (\n\tpkg \"example.com/project/v2\"\n)\n\nvar _ = pkg.F\n", string(got))
+	}
+}
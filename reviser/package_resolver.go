@@ -0,0 +1,168 @@
+package reviser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+	"github.com/incu6us/goimports-reviser/v3/pkg/std"
+)
+
+const (
+	GoListResolverName      = "golist"
+	VendorResolverName      = "vendor"
+	ModuleCacheResolverName = "modcache"
+	HeuristicResolverName   = "heuristic"
+)
+
+// StringToPackageNameResolver will convert string, like "vendor,modcache,heuristic" to the chain of package name
+// resolvers which are tried in order. Allowed resolvers: golist, vendor, modcache, heuristic.
+func StringToPackageNameResolver(s string) (astutil.PackageNameResolver, error) {
+	var resolvers astutil.ChainResolver
+	for _, name := range strings.Split(s, stringValueSeparator) {
+		switch strings.TrimSpace(name) {
+		case GoListResolverName:
+			resolvers = append(resolvers, astutil.GoListResolver{})
+		case VendorResolverName:
+			resolvers = append(resolvers, astutil.VendorResolver{})
+		case ModuleCacheResolverName:
+			resolvers = append(resolvers, astutil.ModuleCacheResolver{})
+		case HeuristicResolverName:
+			resolvers = append(resolvers, astutil.HeuristicResolver{})
+		case "":
+			continue
+		default:
+			return nil, fmt.Errorf(`unknown package name resolver: %q`, strings.TrimSpace(name))
+		}
+	}
+
+	if len(resolvers) == 0 {
+		return nil, fmt.Errorf(`package name resolvers are not set`)
+	}
+
+	return resolvers, nil
+}
+
+// resolvePackageImports resolves names of the file's imports with the configured resolver. Resolvers which depend on
+// the build configuration(ex.: golist) are used for every build configuration of the file. Errors are reported as
+// diagnostics, names which were resolved are returned.
+func (f *SourceFile) resolvePackageImports(fset *token.FileSet, file *ast.File) astutil.PackageImports {
+	importPaths := make([]string, 0, len(file.Imports))
+	for _, importSpec := range file.Imports {
+		importPaths = append(importPaths, strings.Trim(importSpec.Path.Value, `"`))
+	}
+
+	resolvers := []astutil.PackageNameResolver{f.packageNameResolver}
+	if contextResolver, ok := f.packageNameResolver.(astutil.BuildContextResolver); ok {
+		resolvers = resolvers[:0]
+		for _, ctx := range f.fileBuildContexts(file) {
+			resolvers = append(resolvers, contextResolver.WithBuildContext(ctx))
+		}
+	}
+
+	result := astutil.PackageImports{}
+	reported := map[string]struct{}{}
+	for _, resolver := range resolvers {
+		packageImports, err := resolver.ResolvePackageNames(filepath.Dir(f.filePath), importPaths)
+		if err != nil {
+			if _, ok := reported[err.Error()]; !ok {
+				reported[err.Error()] = struct{}{}
+				f.report(fset, file.Package, packageLoadCategory, "failed to resolve package names: %s", err)
+			}
+		}
+
+		for importPath, name := range packageImports {
+			result[importPath] = name
+		}
+	}
+
+	return result
+}
+
+// loadPackageExports loads exported names of the packages in every build configuration of the file. Names are loaded
+// with `go list` unless a package name resolver is set, then it's used if it implements astutil.PackageExportsResolver.
+// Errors of the resolver are reported as diagnostics. Packages which exports are unknown are omitted from the result.
+func (f *SourceFile) loadPackageExports(fset *token.FileSet, file *ast.File, importPaths []string) (astutil.PackageExports, error) {
+	result := astutil.PackageExports{}
+	for _, ctx := range f.fileBuildContexts(file) {
+		var resolver astutil.PackageNameResolver = astutil.GoListResolver{Context: ctx}
+		if f.packageNameResolver != nil {
+			resolver = f.packageNameResolver
+			if contextResolver, ok := resolver.(astutil.BuildContextResolver); ok {
+				resolver = contextResolver.WithBuildContext(ctx)
+			}
+		}
+
+		exportsResolver, ok := resolver.(astutil.PackageExportsResolver)
+		if !ok {
+			return result, nil
+		}
+
+		exports, err := exportsResolver.ResolvePackageExports(filepath.Dir(f.filePath), importPaths)
+		if err != nil {
+			if f.packageNameResolver == nil {
+				return nil, err
+			}
+			f.report(fset, file.Package, packageLoadCategory, "failed to resolve package exports: %s", err)
+		}
+
+		for importPath, names := range exports {
+			if result[importPath] == nil {
+				result[importPath] = map[string]struct{}{}
+			}
+			for name := range names {
+				result[importPath][name] = struct{}{}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// typesImporter returns the importer of the file's imports for type-checking. Packages are not loaded: names of std
+// packages are taken from the import path, aliased imports are named by the alias and names of other packages are
+// loaded only if they are imported without an alias. Imports with unknown names fail to import. Exports are used for
+// packages which objects should be resolved(ex.: dot imports).
+func (f *SourceFile) typesImporter(
+	fset *token.FileSet,
+	file *ast.File,
+	exports astutil.PackageExports,
+) (types.ImporterFrom, error) {
+	names := astutil.PackageImports{}
+	var shouldLoadNames bool
+	for _, importSpec := range file.Imports {
+		imprt := strings.Trim(importSpec.Path.Value, `"`)
+		if _, ok := std.StdPackages[imprt]; ok {
+			names[imprt] = astutil.GuessPackageName(imprt)
+			continue
+		}
+
+		switch {
+		case imprt == "C" || importSpec.Name.String() == "_":
+		case importSpec.Name == nil || importSpec.Name.Name == ".":
+			shouldLoadNames = true
+		default:
+			names[imprt] = importSpec.Name.Name
+		}
+	}
+
+	if shouldLoadNames {
+		packageImports, err := f.loadPackageImports(fset, file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, importSpec := range file.Imports {
+			imprt := strings.Trim(importSpec.Path.Value, `"`)
+			if _, ok := names[imprt]; !ok && packageImports[imprt] != "" {
+				names[imprt] = packageImports[imprt]
+			}
+		}
+	}
+
+	return astutil.NewPackageImporter(names, exports), nil
+}
@@ -0,0 +1,158 @@
+package reviser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+)
+
+func TestStringToPackageNameResolver(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    astutil.PackageNameResolver
+		wantErr string
+	}{
+		{
+			name:  "success",
+			value: "vendor, modcache,heuristic,",
+			want: astutil.ChainResolver{
+				astutil.VendorResolver{},
+				astutil.ModuleCacheResolver{},
+				astutil.HeuristicResolver{},
+			},
+		},
+		{
+			name:  "go list",
+			value: "golist",
+			want:  astutil.ChainResolver{astutil.GoListResolver{}},
+		},
+		{
+			name:    "unknown resolver",
+			value:   "vendor,gopath",
+			wantErr: `unknown package name resolver: "gopath"`,
+		},
+		{
+			name:    "empty",
+			value:   " , ",
+			wantErr: `package name resolvers are not set`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := StringToPackageNameResolver(tt.value)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSourceFile_Fix_WithPackageNameResolver(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(
+		filepath.Join(tmpDir, "go.mod"),
+		[]byte("module example.com/testproject\n\ngo 1.21\n"),
+		0o644,
+	))
+
+	vendoredDir := filepath.Join(tmpDir, "vendor", "example.com", "dep", "v2")
+	require.NoError(t, os.MkdirAll(vendoredDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(vendoredDir, "dep.go"), []byte("package realdep\n"), 0o644))
+
+	fileContent := `package testproject
+
+import (
+	"example.com/dep/v2"
+	"example.com/go-other/v3"
+)
+
+var _ = realdep.Value
+var _ = other.Value
+`
+	filePath := filepath.Join(tmpDir, "main.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(fileContent), 0o644))
+
+	sourceFile := NewSourceFile("example.com/testproject", filePath)
+	got, _, hasChange, err := sourceFile.Fix(
+		WithUsingAliasForVersionSuffix,
+		WithPackageNameResolver(astutil.ChainResolver{astutil.VendorResolver{}, astutil.HeuristicResolver{}}),
+	)
+	require.NoError(t, err)
+
+	assert.True(t, hasChange)
+	assert.Equal(t, `package testproject
+
+import (
+	realdep "example.com/dep/v2"
+	other "example.com/go-other/v3"
+)
+
+var _ = realdep.Value
+var _ = other.Value
+`, string(got))
+	assert.Empty(t, sourceFile.Diagnostics())
+}
+
+func TestSourceFile_Fix_WithGoListResolverBuildContexts(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(
+		filepath.Join(tmpDir, "go.mod"),
+		[]byte("module example.com/testproject\n\ngo 1.21\n"),
+		0o644,
+	))
+
+	depDir := filepath.Join(tmpDir, "go-dep")
+	require.NoError(t, os.MkdirAll(depDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(depDir, "dep.go"), []byte("package realdep\n\nvar Value int\n"), 0o644))
+
+	fileContent := `//go:build integration
+
+package testproject
+
+import (
+	realdep "example.com/testproject/go-dep"
+)
+
+var _ = realdep.Value
+`
+	filePath := filepath.Join(tmpDir, "main.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(fileContent), 0o644))
+
+	resolver, err := StringToPackageNameResolver(GoListResolverName)
+	require.NoError(t, err)
+
+	sourceFile := NewSourceFile("example.com/testproject", filePath)
+	got, _, hasChange, err := sourceFile.Fix(WithRemovingRedundantAliases, WithPackageNameResolver(resolver))
+	require.NoError(t, err)
+
+	assert.True(t, hasChange)
+	assert.Equal(t, `//go:build integration
+
+package testproject
+
+import (
+	"example.com/testproject/go-dep"
+)
+
+var _ = realdep.Value
+`, string(got))
+	assert.Empty(t, sourceFile.Diagnostics())
+}
@@ -0,0 +1,64 @@
+package reviser
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+)
+
+// preservedImportGroups returns imports grouped as in the original source: a group ends with an empty line or with the
+// end of the import declaration. Imports are sorted inside of their groups only. Std imports are moved to the first
+// group if shouldMoveStdImportsFirst is set.
+func (f *SourceFile) preservedImportGroups(
+	fset *token.FileSet,
+	file *ast.File,
+	importsWithMetadata map[string]*commentsMetadata,
+) [][]string {
+	groupIdxByPos := map[token.Pos]int{}
+	idx := -1
+	for _, decl := range file.Decls {
+		dd, ok := decl.(*ast.GenDecl)
+		if !ok || dd.Tok != token.IMPORT || isSeparateImportDecl(dd) {
+			continue
+		}
+
+		prevLine := -1
+		idx++
+		for _, spec := range dd.Specs {
+			importSpec := spec.(*ast.ImportSpec)
+
+			start := importSpec.Path.Pos()
+			if importSpec.Doc != nil {
+				start = importSpec.Doc.Pos()
+			}
+			if prevLine != -1 && fset.Position(start).Line > prevLine+1 {
+				idx++
+			}
+			groupIdxByPos[importSpec.Pos()] = idx
+
+			end := importSpec.End()
+			if importSpec.Comment != nil {
+				end = importSpec.Comment.End()
+			}
+			prevLine = fset.Position(end).Line
+		}
+	}
+
+	// the first group is for std imports
+	groups := make([][]string, idx+2)
+	for imprt, metadata := range importsWithMetadata {
+		i := groupIdxByPos[metadata.Pos] + 1
+		if f.shouldMoveStdImportsFirst &&
+			importGroup(f.projectName, f.companyPackagePrefixes, skipPackageAlias(imprt)) == StdImportsOrder {
+			i = 0
+		}
+		groups[i] = append(groups[i], imprt)
+	}
+
+	for i := range groups {
+		sort.Strings(groups[i])
+		groups[i] = f.restoreBlankImportsOrder(groups[i], importsWithMetadata)
+	}
+
+	return groups
+}
@@ -0,0 +1,201 @@
+package reviser
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+	"github.com/incu6us/goimports-reviser/v3/pkg/cache"
+	"github.com/incu6us/goimports-reviser/v3/pkg/module"
+	"github.com/incu6us/goimports-reviser/v3/pkg/std"
+)
+
+// resultCacheVersion is changed when the format of the cached result changes
+const resultCacheVersion = "1"
+
+var stdListVersion = sync.OnceValue(func() string {
+	packages := make([]string, 0, len(std.StdPackages))
+	for pkg := range std.StdPackages {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	return cache.Key([]byte(strings.Join(packages, "\n")))
+})
+
+// cachedResult is the result of SourceFile.Fix which is stored in the cache
+type cachedResult struct {
+	// Content is set only if it's different from the original content
+	Content     []byte       `json:"content,omitempty"`
+	HasChanged  bool         `json:"has_changed"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// resultCacheKey returns the key of the result of the file with the content. Besides the content, the key depends on
+// the options, the file path, go.mod of the module(versions of the dependencies and the Go version), the tool version,
+// the list of std packages and signatures of the imported packages which sources can change(see
+// packageSignaturesKey).
+func (f *SourceFile) resultCacheKey(content []byte) string {
+	var goMod []byte
+	if f.filePath != StandardInput {
+		if root, err := module.GoModRootPath(filepath.Dir(f.filePath)); err == nil && root != "" {
+			goMod, _ = os.ReadFile(filepath.Join(root, "go.mod"))
+		}
+	}
+
+	return cache.Key(
+		[]byte(resultCacheVersion),
+		[]byte(f.resultCacheSalt),
+		[]byte(stdListVersion()),
+		[]byte(f.optionsFingerprint()),
+		[]byte(f.filePath),
+		goMod,
+		[]byte(f.packageSignaturesKey(content)),
+		content,
+	)
+}
+
+// packageSignaturesKey returns signatures(package name, exported names and deprecation) of the packages imported by
+// the content, if the result depends on them: ex.: a local package is renamed or its exported name is removed. Only
+// packages of the main module, replacement directories and vendor/ are included, others can't change without go.mod.
+func (f *SourceFile) packageSignaturesKey(content []byte) string {
+	if !f.usesPackageMetadata() || f.filePath == StandardInput {
+		return ""
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), f.filePath, content, parser.ImportsOnly)
+	if err != nil {
+		return ""
+	}
+
+	importPaths := make([]string, 0, len(file.Imports))
+	for _, importSpec := range file.Imports {
+		importPaths = append(importPaths, strings.Trim(importSpec.Path.Value, `"`))
+	}
+
+	signatures, _ := astutil.LoadPackageSignatures(filepath.Dir(f.filePath), importPaths)
+
+	var key strings.Builder
+	for _, importPath := range importPaths {
+		if signature, ok := signatures[importPath]; ok {
+			fmt.Fprintf(&key, "%s\n%s\n", importPath, signature)
+		}
+	}
+
+	return key.String()
+}
+
+// usesPackageMetadata checks if the options require names, exports or documentation of the imported packages
+func (f *SourceFile) usesPackageMetadata() bool {
+	return f.shouldRemoveUnusedImports || f.shouldUseAliasForVersionSuffix || f.shouldRemoveRedundantAliases ||
+		f.shouldResolveNameCollisions || f.shouldEliminateDotImports || f.shouldCheckDeprecatedImports ||
+		len(f.canonicalAliases) > 0
+}
+
+// optionsFingerprint returns the string which is equal for equal resolved options. Options are listed explicitly, so
+// state of the file(path, loaded packages, diagnostics, caches) doesn't get into the fingerprint; an option which
+// changes the result must be added here.
+func (f *SourceFile) optionsFingerprint() string {
+	options := []string{
+		"project-name=" + f.projectName,
+		"rm-unused=" + strconv.FormatBool(f.shouldRemoveUnusedImports),
+		"set-alias=" + strconv.FormatBool(f.shouldUseAliasForVersionSuffix),
+		"rm-redundant-aliases=" + strconv.FormatBool(f.shouldRemoveRedundantAliases),
+		"format=" + strconv.FormatBool(f.shouldFormatCode),
+		"skip-generated=" + strconv.FormatBool(f.shouldSkipAutoGenerated),
+		"separate-named=" + strconv.FormatBool(f.shouldSeparateNamedImports),
+		"side-effect-group=" + strconv.FormatBool(f.hasSeparateSideEffectGroup),
+		"company-prefixes=" + strings.Join(f.companyPackagePrefixes, ","),
+		"imports-order=" + importsOrdersString(f.importsOrders),
+		"canonical-aliases=" + mapString(f.canonicalAliases),
+		"alias-template=" + f.aliasTemplate,
+		"resolve-collisions=" + strconv.FormatBool(f.shouldResolveNameCollisions),
+		"collision-priorities=" + importsOrdersString(f.collisionPriorities),
+		"rm-dot-imports=" + strconv.FormatBool(f.shouldEliminateDotImports),
+		"dot-imports-allowlist=" + strings.Join(f.dotImportsAllowlist, ","),
+		"import-hygiene=" + strconv.FormatBool(f.shouldCheckImportHygiene),
+		"testing-packages=" + strings.Join(f.testingPackages, ","),
+		"keep-group-headers=" + strconv.FormatBool(f.shouldKeepGroupHeaders),
+		"group-headers=" + mapString(f.groupHeaders),
+		"keep-blank-order=" + strconv.FormatBool(f.shouldPreserveBlankImportsOrder),
+		"blank-order-paths=" + strings.Join(f.blankImportsOrderPaths, ","),
+		"preserve-groups=" + strconv.FormatBool(f.shouldPreserveImportGroups),
+		"std-first=" + strconv.FormatBool(f.shouldMoveStdImportsFirst),
+		"build-contexts=" + buildContextsString(f.buildContexts),
+		"package-name-resolver=" + astutil.ResolverString(f.packageNameResolver),
+		"add-missing=" + strconv.FormatBool(f.shouldAddMissingImports),
+		"modernize=" + strconv.FormatBool(f.shouldModernize),
+		"check-deprecated=" + strconv.FormatBool(f.shouldCheckDeprecatedImports),
+		"check-major-versions=" + strconv.FormatBool(f.shouldCheckMajorVersions),
+		"fix-major-versions=" + strconv.FormatBool(f.shouldFixMajorVersions),
+	}
+	sort.Strings(options)
+
+	return strings.Join(options, "\n")
+}
+
+func importsOrdersString(orders ImportsOrders) string {
+	values := make([]string, 0, len(orders))
+	for _, order := range orders {
+		values = append(values, string(order))
+	}
+
+	return strings.Join(values, ",")
+}
+
+func buildContextsString(contexts []astutil.BuildContext) string {
+	values := make([]string, 0, len(contexts))
+	for _, ctx := range contexts {
+		values = append(values, ctx.String())
+	}
+
+	return strings.Join(values, ",")
+}
+
+// mapString returns pairs of the map sorted by keys, ex.: "a=b,c=d"
+func mapString[K ~string, V ~string](m map[K]V) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, strconv.Quote(string(k))+"="+strconv.Quote(string(v)))
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+func (f *SourceFile) cachedResult(key string) (cachedResult, bool) {
+	data, ok := f.resultCache.Get(key)
+	if !ok {
+		return cachedResult{}, false
+	}
+
+	var result cachedResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return cachedResult{}, false
+	}
+
+	return result, true
+}
+
+// cacheResult stores the result of the file. Failures are ignored, the file is just fixed again next time.
+func (f *SourceFile) cacheResult(key string, content []byte, hasChanged bool) {
+	result := cachedResult{HasChanged: hasChanged, Diagnostics: f.diagnostics}
+	if hasChanged {
+		result.Content = content
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+
+	_ = f.resultCache.Put(key, data)
+}
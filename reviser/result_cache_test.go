@@ -0,0 +1,196 @@
+package reviser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/incu6us/goimports-reviser/v3/pkg/astutil"
+	"github.com/incu6us/goimports-reviser/v3/pkg/cache"
+)
+
+func TestSourceFile_Fix_WithResultCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	resultCache, err := cache.New(cacheDir, 0)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package testdata
+
+import (
+	"strings"
+
+	_ "embed"
+	"fmt"
+)
+
+func main() {
+	fmt.Println(strings.ToLower("A"))
+}
+`), 0o644))
+
+	want := `package testdata
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+func main() {
+	fmt.Println(strings.ToLower("A"))
+}
+`
+
+	countEntries := func() int {
+		var count int
+		require.NoError(t, filepath.WalkDir(cacheDir, func(_ string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				count++
+			}
+			return err
+		}))
+		return count
+	}
+
+	fix := func(options ...SourceFileOption) (string, bool, []Diagnostic) {
+		sourceFile := NewSourceFile("testdata", filePath)
+		got, _, hasChange, err := sourceFile.Fix(options...)
+		require.NoError(t, err)
+		return string(got), hasChange, sourceFile.Diagnostics()
+	}
+
+	options := []SourceFileOption{WithImportHygieneRules(nil), WithResultCache(resultCache, "v1.0.0")}
+
+	got, hasChange, diagnostics := fix(options...)
+	assert.Equal(t, want, got)
+	assert.True(t, hasChange)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, 1, countEntries())
+
+	// the result and diagnostics are restored from the cache
+	gotCached, hasChangeCached, diagnosticsCached := fix(options...)
+	assert.Equal(t, got, gotCached)
+	assert.Equal(t, hasChange, hasChangeCached)
+	assert.Equal(t, diagnostics, diagnosticsCached)
+	assert.Equal(t, 1, countEntries())
+
+	// other options and tool versions have their own results
+	got, _, diagnostics = fix(WithResultCache(resultCache, "v1.0.0"))
+	assert.Equal(t, want, got)
+	assert.Empty(t, diagnostics)
+	assert.Equal(t, 2, countEntries())
+
+	fix(WithImportHygieneRules(nil), WithResultCache(resultCache, "v1.1.0"))
+	assert.Equal(t, 3, countEntries())
+
+	// the changed content is fixed again
+	require.NoError(t, os.WriteFile(filePath, []byte(want), 0o644))
+	got, hasChange, _ = fix(options...)
+	assert.Equal(t, want, got)
+	assert.False(t, hasChange)
+	assert.Equal(t, 4, countEntries())
+
+	got, hasChange, _ = fix(options...)
+	assert.Equal(t, want, got)
+	assert.False(t, hasChange)
+	assert.Equal(t, 4, countEntries())
+}
+
+func TestSourceFile_optionsFingerprint(t *testing.T) {
+	fingerprint := func(filePath string, options ...SourceFileOption) string {
+		f := NewSourceFile("testdata", filePath)
+		for _, option := range options {
+			require.NoError(t, option(f))
+		}
+		return f.optionsFingerprint()
+	}
+
+	assert.Equal(
+		t,
+		fingerprint("a.go", WithRemovingUnusedImports, WithPackageImportsCache(NewPackageImportsCache())),
+		fingerprint("b.go", WithRemovingUnusedImports, WithPackageImportsCache(NewPackageImportsCache())),
+	)
+	assert.NotEqual(t, fingerprint("a.go", WithRemovingUnusedImports), fingerprint("a.go", WithCodeFormatting))
+	assert.NotEqual(
+		t,
+		fingerprint("a.go", WithCanonicalAliases(map[string]string{"fmt": "f"})),
+		fingerprint("a.go", WithCanonicalAliases(map[string]string{"fmt": "ff"})),
+	)
+
+	resolver := astutil.ChainResolver{astutil.VendorResolver{}, astutil.HeuristicResolver{}}
+	assert.Equal(
+		t,
+		fingerprint("a.go", WithPackageNameResolver(resolver)),
+		fingerprint("b.go", WithPackageNameResolver(astutil.ChainResolver{&astutil.VendorResolver{}, resolver[1]})),
+	)
+	assert.Contains(t, fingerprint("a.go", WithPackageNameResolver(resolver)), "package-name-resolver=vendor,heuristic")
+	assert.NotEqual(
+		t,
+		fingerprint("a.go", WithPackageNameResolver(resolver)),
+		fingerprint("a.go", WithPackageNameResolver(astutil.HeuristicResolver{})),
+	)
+}
+
+func TestSourceFile_Fix_WithResultCacheAndChangedLocalPackage(t *testing.T) {
+	resultCache, err := cache.New(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(
+		filepath.Join(dir, "go.mod"),
+		[]byte("module example.com/testproject\n\ngo 1.21\n"),
+		0o644,
+	))
+
+	depDir := filepath.Join(dir, "go-dep")
+	require.NoError(t, os.MkdirAll(depDir, 0o755))
+	writeDep := func(content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(depDir, "dep.go"), []byte(content), 0o644))
+	}
+	writeDep("package realdep\n\nvar Value int\n")
+
+	filePath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package testproject
+
+import (
+	"example.com/testproject/go-dep"
+)
+
+var _ = realdep.Value
+`), 0o644))
+
+	fix := func() string {
+		got, _, _, err := NewSourceFile("example.com/testproject", filePath).Fix(
+			WithUsingAliasForVersionSuffix, WithResultCache(resultCache, "v1.0.0"),
+		)
+		require.NoError(t, err)
+		return string(got)
+	}
+
+	want := `package testproject
+
+import (
+	realdep "example.com/testproject/go-dep"
+)
+
+var _ = realdep.Value
+`
+	assert.Equal(t, want, fix())
+	assert.Equal(t, want, fix())
+
+	// the package is renamed, so the cached result is stale
+	writeDep("package dep\n\nvar Value int\n")
+	assert.Equal(t, `package testproject
+
+import (
+	dep "example.com/testproject/go-dep"
+)
+
+var _ = realdep.Value
+`, fix())
+}
@@ -0,0 +1,5 @@
+package log
+
+func Info(string) {}
+
+func Println(...any) {}
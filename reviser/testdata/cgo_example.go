@@ -0,0 +1,13 @@
+package testdata
+
+import (
+	"strings"
+	/*
+	#cgo LDFLAGS: -lm
+	#include <math.h>
+	*/
+	"C" // cgo
+	"fmt"
+)
+
+// nolint:gomnd
@@ -0,0 +1,7 @@
+package testdata
+
+// #include <stdlib.h>
+import "C"
+
+//go:embed example.go
+var content string